@@ -0,0 +1,250 @@
+package appfile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir holds the application profiles ufw reads.
+const Dir = "/etc/ufw/applications.d"
+
+// Section is one "[Name]" profile of an applications.d file.
+type Section struct {
+	Name   string
+	Values map[string]string // lowercase keys: title, description, ports
+}
+
+// File is an applications.d file. A file may define several profiles, e.g.
+// "Nginx Full", "Nginx HTTP" and "Nginx HTTPS" ship together in one file.
+type File struct {
+	Path     string
+	Package  string // package owning the file, empty for files created locally
+	Sections []Section
+	lines    []string // original lines, kept to preserve comments on rewrite
+}
+
+// backupSuffixes mark package manager and editor leftovers next to real profiles.
+var backupSuffixes = []string{"~", ".dpkg-old", ".dpkg-dist", ".dpkg-new", ".rpmsave", ".rpmnew", ".bak"}
+
+// LoadDir parses every profile file in dir and looks up the packages owning them.
+func LoadDir(dir string) ([]*File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*File
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || isBackup(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue // unreadable files cannot define profiles for ufw either
+		}
+		files = append(files, Parse(path, string(content)))
+	}
+
+	owners := Owners(paths(files))
+	for _, file := range files {
+		file.Package = owners[file.Path]
+	}
+	return files, nil
+}
+
+func paths(files []*File) []string {
+	result := make([]string, len(files))
+	for i, file := range files {
+		result[i] = file.Path
+	}
+	return result
+}
+
+func isBackup(name string) bool {
+	for _, suffix := range backupSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse reads the sections of an INI profile file. Keys outside a section,
+// comments (# and ;) and lines without "=" are kept but ignored.
+func Parse(path, content string) *File {
+	file := &File{Path: path, lines: strings.Split(content, "\n")}
+	for _, line := range file.lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case isSectionHeader(trimmed):
+			file.Sections = append(file.Sections, Section{Name: trimmed[1 : len(trimmed)-1], Values: map[string]string{}})
+		case len(file.Sections) == 0, trimmed == "", strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, ";"):
+		default:
+			if key, value, ok := strings.Cut(trimmed, "="); ok {
+				current := file.Sections[len(file.Sections)-1]
+				current.Values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return file
+}
+
+func isSectionHeader(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") && len(trimmed) > 2
+}
+
+// Find returns the file defining the profile name.
+func Find(files []*File, name string) (*File, bool) {
+	for _, file := range files {
+		if _, ok := file.Section(name); ok {
+			return file, true
+		}
+	}
+	return nil, false
+}
+
+func (f *File) Section(name string) (Section, bool) {
+	for _, section := range f.Sections {
+		if section.Name == name {
+			return section, true
+		}
+	}
+	return Section{}, false
+}
+
+// sectionRange returns the lines of section name, from its header up to the
+// next header or the end of the file.
+func (f *File) sectionRange(name string) (int, int, bool) {
+	start := -1
+	for i, line := range f.lines {
+		trimmed := strings.TrimSpace(line)
+		if !isSectionHeader(trimmed) {
+			continue
+		}
+		if start != -1 {
+			return start, i, true
+		}
+		if trimmed == "["+name+"]" {
+			start = i
+		}
+	}
+	return start, len(f.lines), start != -1
+}
+
+// SetValues sets keys of the section, keeping its other lines; keys the
+// section lacks are added after its last entry.
+func (f *File) SetValues(name string, values map[string]string) error {
+	start, end, ok := f.sectionRange(name)
+	if !ok {
+		return fmt.Errorf("no [%s] section in %s", name, f.Path)
+	}
+
+	section := []string{f.lines[start]}
+	written := map[string]bool{}
+	for _, line := range f.lines[start+1 : end] {
+		key, _, found := strings.Cut(strings.TrimSpace(line), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if value, ok := values[key]; found && ok {
+			if !written[key] {
+				section = append(section, key+"="+value)
+				written[key] = true
+			}
+			continue
+		}
+		section = append(section, line)
+	}
+
+	// blank lines separating the next section stay at the end
+	trailing := len(section)
+	for trailing > 1 && strings.TrimSpace(section[trailing-1]) == "" {
+		trailing--
+	}
+	var missing []string
+	for _, key := range sortedKeys(values) {
+		if !written[key] {
+			missing = append(missing, key+"="+values[key])
+		}
+	}
+	section = append(section[:trailing:trailing], append(missing, section[trailing:]...)...)
+
+	f.lines = append(f.lines[:start:start], append(section, f.lines[end:]...)...)
+	f.reparse()
+	return nil
+}
+
+// sortedKeys orders the keys like ufw's own profiles: title, description, ports.
+func sortedKeys(values map[string]string) []string {
+	order := map[string]int{"title": 0, "description": 1, "ports": 2}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, iKnown := order[keys[i]]
+		oj, jKnown := order[keys[j]]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// RemoveSection deletes the section and its lines, the other profiles of the file stay.
+func (f *File) RemoveSection(name string) error {
+	start, end, ok := f.sectionRange(name)
+	if !ok {
+		return fmt.Errorf("no [%s] section in %s", name, f.Path)
+	}
+	f.lines = append(f.lines[:start:start], f.lines[end:]...)
+	f.reparse()
+	return nil
+}
+
+func (f *File) reparse() {
+	parsed := Parse(f.Path, strings.Join(f.lines, "\n"))
+	f.Sections = parsed.Sections
+}
+
+// Save writes the file, or removes it when no section is left.
+func (f *File) Save() error {
+	if len(f.Sections) == 0 {
+		return os.Remove(f.Path)
+	}
+	return os.WriteFile(f.Path, []byte(strings.Join(f.lines, "\n")), 0644)
+}
+
+// Owners maps the paths owned by a dpkg or rpm package to the package name.
+func Owners(paths []string) map[string]string {
+	owners := map[string]string{}
+	if len(paths) == 0 {
+		return owners
+	}
+
+	// dpkg-query fails when any path is unowned but still lists the owned ones
+	if out, err := exec.Command("dpkg-query", append([]string{"-S"}, paths...)...).Output(); err == nil || len(out) > 0 {
+		for _, line := range strings.Split(string(out), "\n") {
+			pkg, path, ok := strings.Cut(line, ": ")
+			if ok {
+				owners[strings.TrimSpace(path)] = strings.Split(pkg, ",")[0]
+			}
+		}
+		return owners
+	}
+
+	for _, path := range paths {
+		out, err := exec.Command("rpm", "-qf", "--queryformat", "%{NAME}", path).Output()
+		if err == nil && len(out) > 0 {
+			owners[path] = strings.TrimSpace(string(out))
+		}
+	}
+	return owners
+}
@@ -0,0 +1,107 @@
+package appfile
+
+import (
+	"strings"
+	"testing"
+)
+
+const nginxProfiles = `# nginx profiles
+[Nginx HTTP]
+title=Web Server
+Description=Small, but very powerful
+ports=80/tcp
+
+[Nginx HTTPS]
+title=Web Server (HTTPS)
+ports=443/tcp`
+
+func TestSetValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		values  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "existing key",
+			section: "Nginx HTTP",
+			values:  map[string]string{"ports": "8080/tcp"},
+			want:    strings.Replace(nginxProfiles, "ports=80/tcp", "ports=8080/tcp", 1),
+		},
+		{
+			name:    "key in another case",
+			section: "Nginx HTTP",
+			values:  map[string]string{"description": "Fast"},
+			want:    strings.Replace(nginxProfiles, "Description=Small, but very powerful", "description=Fast", 1),
+		},
+		{
+			name:    "missing key before the blank line",
+			section: "Nginx HTTP",
+			values:  map[string]string{"x-note": "local", "title": "Nginx"},
+			want:    strings.Replace(strings.Replace(nginxProfiles, "ports=80/tcp\n", "ports=80/tcp\nx-note=local\n", 1), "title=Web Server\n", "title=Nginx\n", 1),
+		},
+		{
+			name:    "missing keys at the end, in profile order",
+			section: "Nginx HTTPS",
+			values:  map[string]string{"ports": "443/tcp|8443/tcp", "description": "TLS"},
+			want:    strings.Replace(nginxProfiles, "ports=443/tcp", "ports=443/tcp|8443/tcp\ndescription=TLS", 1),
+		},
+		{
+			name:    "unknown section",
+			section: "Apache",
+			values:  map[string]string{"ports": "80/tcp"},
+			want:    nginxProfiles,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse("nginx", nginxProfiles)
+			err := f.SetValues(tt.section, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got := strings.Join(f.lines, "\n"); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			section, _ := f.Section(tt.section)
+			for key, value := range tt.values {
+				if !tt.wantErr && section.Values[key] != value {
+					t.Errorf("parsed %s: got %q, want %q", key, section.Values[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		want    string
+		wantErr bool
+	}{
+		{"first", "Nginx HTTP", "# nginx profiles\n[Nginx HTTPS]\ntitle=Web Server (HTTPS)\nports=443/tcp", false},
+		{"last", "Nginx HTTPS", "# nginx profiles\n[Nginx HTTP]\ntitle=Web Server\nDescription=Small, but very powerful\nports=80/tcp\n", false},
+		{"unknown", "Nginx", nginxProfiles, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse("nginx", nginxProfiles)
+			err := f.RemoveSection(tt.section)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got := strings.Join(f.lines, "\n"); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			if _, ok := f.Section(tt.section); ok {
+				t.Errorf("section %s still parsed", tt.section)
+			}
+			if !tt.wantErr && len(f.Sections) != 1 {
+				t.Errorf("got %d sections, want 1", len(f.Sections))
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package blocklist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/ipset"
+	"fwtui/domain/state"
+	"fwtui/domain/ufw"
+	"fwtui/utils/netext"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type Mode string
+
+const (
+	ModeRules Mode = "rules" // one ufw deny rule per network, tagged [blocklist:name]
+	ModeIpset Mode = "ipset" // an ipset dropped in before.rules, for large lists
+)
+
+// MaxRules is the largest list applied as ufw rules; every rule is a ufw call
+// and ufw slows down with thousands of rules.
+const MaxRules = 500
+
+const storeFile = "blocklists.json"
+
+// ipset names are limited to 31 characters and the longest set built from a
+// list name is "fwtui-bl-<name>-v6-tmp", so names are at most 15 characters.
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,14}$`)
+
+// List is an imported blocklist, kept so it can be updated or removed as a whole.
+type List struct {
+	Name      string    `json:"name"`
+	Source    string    `json:"source"` // file path or http(s) URL
+	Mode      Mode      `json:"mode"`
+	Entries   int       `json:"entries"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (l List) Tag() entity.Tag {
+	return entity.Tag{Key: "blocklist", Value: l.Name}
+}
+
+func (l List) setName() string {
+	return "fwtui-bl-" + l.Name
+}
+
+func Load() ([]List, error) {
+	data, err := state.ReadFile(storeFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading blocklists: %w", err)
+	}
+	var lists []List
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, fmt.Errorf("parsing blocklists: %w", err)
+	}
+	return lists, nil
+}
+
+func save(lists []List) error {
+	data, err := json.MarshalIndent(lists, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFile(storeFile, data)
+}
+
+// Fetch reads the source and returns its aggregated networks.
+func Fetch(source string) ([]netip.Prefix, int, error) {
+	var content []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		content, err = download(source)
+	} else {
+		content, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %w", source, err)
+	}
+
+	prefixes, skipped := Parse(string(content))
+	if len(prefixes) == 0 {
+		return nil, skipped, fmt.Errorf("no addresses found in %s", source)
+	}
+	return netext.AggregatePrefixes(prefixes), skipped, nil
+}
+
+func download(url string) ([]byte, error) {
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Import fetches a new list, applies it and records it.
+func Import(list List) string {
+	if !nameRegex.MatchString(list.Name) {
+		return "Error: list names use lowercase letters, digits, - and _ (at most 15)"
+	}
+	if strings.HasSuffix(list.Name, "-v6") {
+		// the IPv6 set of list foo is named like the IPv4 set of a list foo-v6
+		return "Error: list names cannot end in -v6"
+	}
+	lists, err := Load()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if lo.ContainsBy(lists, func(l List) bool { return l.Name == list.Name }) {
+		return fmt.Sprintf("Error: a blocklist named %s already exists", list.Name)
+	}
+	return apply(list, lists)
+}
+
+// Update fetches the list again and replaces its entries.
+func Update(list List) string {
+	lists, err := Load()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return apply(list, lists)
+}
+
+func apply(list List, lists []List) string {
+	prefixes, skipped, err := Fetch(list.Source)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if list.Mode == ModeRules && len(prefixes) > MaxRules {
+		return fmt.Sprintf("Error: %d networks are too many for ufw rules (max %d), use ipset mode", len(prefixes), MaxRules)
+	}
+
+	var output string
+	switch list.Mode {
+	case ModeRules:
+		output = replaceRules(list, prefixes)
+	case ModeIpset:
+		if err := ipset.Apply(list.setName(), prefixes); err != nil {
+			return "Error: " + err.Error()
+		}
+		if err := ipset.AddMatchRules(list.setName(), ipset.MatchRule{Target: "DROP"}); err != nil {
+			return "Error: " + err.Error()
+		}
+		output = ufw.Reload()
+	default:
+		return fmt.Sprintf("Error: unknown mode %s", list.Mode)
+	}
+
+	list.Entries = len(prefixes)
+	list.UpdatedAt = time.Now()
+	lists = append(lo.Reject(lists, func(l List, _ int) bool { return l.Name == list.Name }), list)
+	if err := save(lists); err != nil {
+		return output + "Error: saving blocklists: " + err.Error()
+	}
+	return output + fmt.Sprintf("Blocklist %s: %d networks applied, %d lines skipped\n", list.Name, len(prefixes), skipped)
+}
+
+// replaceRules deletes the list's rules and prepends a deny rule per network,
+// so the list is checked before any allow rule.
+func replaceRules(list List, prefixes []netip.Prefix) string {
+	output := removeRules(list)
+	for _, prefix := range prefixes {
+		output += ufw.PrependRule(fmt.Sprintf("deny from %s comment '%s'", prefix, list.Tag()))
+	}
+	return output
+}
+
+func removeRules(list List) string {
+	rules := lo.Filter(entity.ParseRules(ufw.StatusNumbered()), func(rule entity.Rule, _ int) bool {
+		return rule.HasTag(list.Tag())
+	})
+	return entity.DeleteRules(rules)
+}
+
+// Remove deletes everything the list added and forgets it.
+func Remove(list List) string {
+	var output string
+	switch list.Mode {
+	case ModeRules:
+		output = removeRules(list)
+	case ModeIpset:
+		if err := ipset.RemoveMatchRules(list.setName()); err != nil {
+			return "Error: " + err.Error()
+		}
+		output = ufw.Reload()
+		if err := ipset.Destroy(list.setName()); err != nil {
+			return output + "Error: " + err.Error()
+		}
+	}
+
+	lists, err := Load()
+	if err != nil {
+		return output + "Error: " + err.Error()
+	}
+	lists = lo.Reject(lists, func(l List, _ int) bool { return l.Name == list.Name })
+	if err := save(lists); err != nil {
+		return output + "Error: saving blocklists: " + err.Error()
+	}
+	return output + fmt.Sprintf("Blocklist %s removed\n", list.Name)
+}
@@ -0,0 +1,39 @@
+package blocklist
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// Parse reads addresses and networks from a plain list, a CSV file or the
+// Spamhaus DROP format ("1.10.16.0/20 ; SBL256894"). The first field of a
+// line that is an address or network is used; other lines are skipped.
+func Parse(content string) (prefixes []netip.Prefix, skipped int) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if prefix, ok := parseLine(line); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			skipped++
+		}
+	}
+	return prefixes, skipped
+}
+
+func parseLine(line string) (netip.Prefix, bool) {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\t' || r == ' ' || r == '"'
+	})
+	for _, field := range fields {
+		if prefix, err := netip.ParsePrefix(field); err == nil {
+			return prefix.Masked(), true
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			return netip.PrefixFrom(addr, addr.BitLen()), true
+		}
+	}
+	return netip.Prefix{}, false
+}
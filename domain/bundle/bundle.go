@@ -0,0 +1,119 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"fwtui/domain/appfile"
+	"fwtui/domain/entity"
+	"fwtui/utils/result"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Conflict decides what Import does with a profile whose name is already installed.
+type Conflict string
+
+const (
+	ConflictSkip      Conflict = "skip"
+	ConflictOverwrite Conflict = "overwrite"
+	ConflictRename    Conflict = "rename"
+)
+
+// Write saves the profiles to path as one applications.d style file, so a
+// bundle can also be copied to applications.d by hand.
+func Write(path string, profiles []entity.UFWProfile) error {
+	lines := []string{fmt.Sprintf("# %d ufw application profiles, exported by fwtui on %s", len(profiles), time.Now().Format("2006-01-02 15:04"))}
+	for _, p := range profiles {
+		lines = append(lines,
+			"",
+			"["+p.Name+"]",
+			"title="+p.Title,
+			"description="+p.Description,
+			"ports="+strings.Join(p.Ports, "|"),
+		)
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// Read returns the profiles of a bundle.
+func Read(path string) ([]entity.UFWProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []entity.UFWProfile
+	for _, section := range appfile.Parse(path, string(content)).Sections {
+		ports := lo.Compact(strings.Split(section.Values["ports"], "|"))
+		if len(ports) == 0 {
+			return nil, fmt.Errorf("profile %s in %s has no ports", section.Name, path)
+		}
+		profile := entity.UFWProfile{
+			Name:        section.Name,
+			Title:       section.Values["title"],
+			Description: section.Values["description"],
+			Ports:       ports,
+		}
+		// bundles come from other hosts, their names end up in file paths
+		if err := entity.ValidateProfile(profile); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles in %s", path)
+	}
+	return profiles, nil
+}
+
+// Import installs the profiles, resolving name clashes with installed
+// profiles as conflict says. Profiles shipped by a package are never overwritten.
+func Import(profiles []entity.UFWProfile, conflict Conflict) string {
+	var output []string
+	for _, p := range profiles {
+		res := entity.CreateProfile(p)
+		var exists entity.ProfileExistsError
+		if !errors.As(res.Err(), &exists) {
+			output = append(output, message(res))
+			continue
+		}
+
+		switch conflict {
+		case ConflictSkip:
+			output = append(output, fmt.Sprintf("Skipped profile %s: already exists in %s", p.Name, exists.File))
+		case ConflictOverwrite:
+			// CreateProfile defaults these, the update writes them as given
+			p.Title = lo.CoalesceOrEmpty(p.Title, p.Name)
+			p.Description = lo.CoalesceOrEmpty(p.Description, p.Title)
+			res = entity.UpdateProfile(p, false)
+			var owned entity.PackageOwnedError
+			if errors.As(res.Err(), &owned) {
+				output = append(output, fmt.Sprintf("Skipped profile %s: shipped by package %s", p.Name, owned.Package))
+				continue
+			}
+			output = append(output, message(res))
+		case ConflictRename:
+			name := p.Name
+			for i := 2; errors.As(res.Err(), &exists); i++ {
+				p.Name = fmt.Sprintf("%s-%d", name, i)
+				res = entity.CreateProfile(p)
+			}
+			if res.IsOk() {
+				output = append(output, fmt.Sprintf("Profile %s created as %s", name, p.Name))
+				continue
+			}
+			output = append(output, message(res))
+		}
+	}
+	return strings.Join(output, "\n")
+}
+
+func message(res result.Result[string]) string {
+	if res.IsErr() {
+		return "Error: " + res.Err().Error()
+	}
+	return res.Value()
+}
@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	_ "embed"
+	"fmt"
+	"fwtui/domain/appfile"
+	"fwtui/domain/entity"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed defaults.yaml
+var defaults []byte
+
+// SystemDir holds catalog files shipped for every user of the host.
+const SystemDir = "/etc/fwtui/catalog.d"
+
+// DefaultCategory groups entries that name no category.
+const DefaultCategory = "Other"
+
+// Entry is a profile offered for installation.
+type Entry struct {
+	Name        string   `yaml:"name"`
+	Category    string   `yaml:"category"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Ports       []string `yaml:"ports"`
+}
+
+// file is the YAML catalog format; Category applies to entries without their own.
+type file struct {
+	Category string  `yaml:"category"`
+	Profiles []Entry `yaml:"profiles"`
+}
+
+// UserDir is the per-user catalog directory. fwtui runs through sudo, so the
+// invoking user's home is preferred over root's.
+func UserDir() string {
+	home, _ := os.UserHomeDir()
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		if u, err := user.Lookup(sudoUser); err == nil {
+			home = u.HomeDir
+		}
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "fwtui", "catalog.d")
+}
+
+// Load reads the embedded defaults, then SystemDir, then UserDir. Later entries
+// replace earlier ones with the same name. Files that cannot be read are
+// skipped and reported in the returned errors.
+func Load() ([]Entry, []error) {
+	entries, err := parseYAML(defaults)
+	if err != nil {
+		// the defaults are part of the binary, this is a build error
+		panic(fmt.Sprintf("embedded catalog: %v", err))
+	}
+
+	var errs []error
+	for _, dir := range []string{SystemDir, UserDir()} {
+		if dir == "" {
+			continue
+		}
+		loaded, dirErrs := loadDir(dir)
+		errs = append(errs, dirErrs...)
+		entries = merge(entries, loaded)
+	}
+	return entries, errs
+}
+
+func loadDir(dir string) ([]Entry, []error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(paths) == 0 {
+		return nil, nil
+	}
+	sort.Strings(paths)
+
+	var entries []Entry
+	var errs []error
+	for _, path := range paths {
+		var parsed []Entry
+		var err error
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			var data []byte
+			if data, err = os.ReadFile(path); err == nil {
+				parsed, err = parseYAML(data)
+			}
+		case ".ini", ".profile":
+			var data []byte
+			if data, err = os.ReadFile(path); err == nil {
+				parsed, err = parseINI(path, string(data))
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		entries = merge(entries, parsed)
+	}
+	return entries, errs
+}
+
+func parseYAML(data []byte) ([]Entry, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for i := range f.Profiles {
+		f.Profiles[i].Category = lo.CoalesceOrEmpty(f.Profiles[i].Category, f.Category, DefaultCategory)
+	}
+	return validEntries(f.Profiles)
+}
+
+// parseINI reads applications.d style sections with an optional category key.
+func parseINI(path, content string) ([]Entry, error) {
+	var entries []Entry
+	for _, section := range appfile.Parse(path, content).Sections {
+		entries = append(entries, Entry{
+			Name:        section.Name,
+			Category:    lo.CoalesceOrEmpty(section.Values["category"], DefaultCategory),
+			Title:       section.Values["title"],
+			Description: section.Values["description"],
+			Ports:       lo.Compact(strings.Split(section.Values["ports"], "|")),
+		})
+	}
+	return validEntries(entries)
+}
+
+func validEntries(entries []Entry) ([]Entry, error) {
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.Name) == "" || len(entry.Ports) == 0 {
+			return nil, fmt.Errorf("profile %q needs a name and ports", entry.Name)
+		}
+	}
+	return entries, nil
+}
+
+// merge appends overrides to entries, replacing entries of the same name in place.
+func merge(entries, overrides []Entry) []Entry {
+	for _, override := range overrides {
+		_, index, found := lo.FindIndexOf(entries, func(e Entry) bool { return e.Name == override.Name })
+		if found {
+			entries[index] = override
+		} else {
+			entries = append(entries, override)
+		}
+	}
+	return entries
+}
+
+// Categories returns the categories in catalog order.
+func Categories(entries []Entry) []string {
+	return lo.Uniq(lo.Map(entries, func(e Entry, _ int) string { return e.Category }))
+}
+
+func (e Entry) Profile() entity.UFWProfile {
+	return entity.UFWProfile{
+		Name:        e.Name,
+		Title:       e.Title,
+		Description: e.Description,
+		Ports:       e.Ports,
+		Category:    e.Category,
+	}
+}
+
+// InstallableProfiles returns the catalog profiles not installed yet, grouped
+// by category in catalog order.
+func InstallableProfiles() ([]entity.UFWProfile, []error) {
+	entries, errs := Load()
+	installed, _ := entity.LoadInstalledProfiles()
+	installedNames := lo.Map(installed, func(p entity.UFWProfile, _ int) string { return p.Name })
+
+	order := Categories(entries)
+	entries = lo.Filter(entries, func(e Entry, _ int) bool { return !lo.Contains(installedNames, e.Name) })
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lo.IndexOf(order, entries[i].Category) < lo.IndexOf(order, entries[j].Category)
+	})
+	return lo.Map(entries, func(e Entry, _ int) entity.UFWProfile { return e.Profile() }), errs
+}
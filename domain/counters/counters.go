@@ -0,0 +1,163 @@
+package counters
+
+import (
+	"bufio"
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/state"
+	"fwtui/domain/ufw"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type Counter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// userChains are the chains ufw puts user rules in.
+var userChains = []string{"ufw-user-input", "ufw-user-output", "ufw-user-forward"}
+
+const resetStampFile = "counters-reset"
+
+// chainRef points at one iptables rule: the n-th rule of a chain.
+type chainRef struct {
+	chain string
+	index int
+}
+
+// Load returns the counters of every rule by rule number. ufw writes each
+// rule as a "### tuple ###" comment followed by its iptables rules into
+// user.rules and user6.rules, in the same order as `ufw status numbered`,
+// so counters are matched by position within each chain.
+func Load(rules []entity.Rule) (map[int]Counter, error) {
+	result := map[int]Counter{}
+	for _, v6 := range []bool{false, true} {
+		path := lo.Ternary(v6, "/etc/ufw/user6.rules", "/etc/ufw/user.rules")
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		tuples := parseTuples(file)
+		file.Close()
+
+		chains := map[string][]Counter{}
+		for _, chain := range userChains {
+			chains[chain] = parseChainListing(ufw.ListChain(v6, chain))
+		}
+
+		i := 0
+		for _, rule := range rules {
+			if rule.V6 != v6 {
+				continue
+			}
+			if i >= len(tuples) {
+				break
+			}
+			result[rule.Number] = sum(tuples[i], chains)
+			i++
+		}
+	}
+	return result, nil
+}
+
+// Reset zeroes the counters of all user chains and remembers when it happened.
+func Reset() string {
+	var output string
+	for _, v6 := range []bool{false, true} {
+		for _, chain := range userChains {
+			output += ufw.ZeroChain(v6, chain)
+		}
+	}
+	stamp := time.Now().Format(time.RFC3339)
+	if err := state.WriteFile(resetStampFile, []byte(stamp)); err != nil {
+		output += fmt.Sprintf("Error: saving reset time: %s\n", err)
+	}
+	return output
+}
+
+// Since returns when the counters were last reset from fwtui. ufw reloads
+// also reset them, so the counters may cover a shorter period.
+func Since() (time.Time, bool) {
+	data, err := state.ReadFile(resetStampFile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	since, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return since, err == nil
+}
+
+// parseTuples returns, for every rule tuple in a ufw rules file, the iptables
+// rules that decide its verdict. Rules without a target (rate limit
+// bookkeeping) and jumps to logging chains would count packets twice.
+func parseTuples(r io.Reader) [][]chainRef {
+	var tuples [][]chainRef
+	indices := map[string]int{}
+	inTuple := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "### tuple ###"):
+			tuples = append(tuples, nil)
+			inTuple = true
+		case strings.HasPrefix(line, "#"), line == "":
+			inTuple = false
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.Fields(line)
+			chain := fields[1]
+			index := indices[chain]
+			indices[chain]++
+
+			target := jumpTarget(fields)
+			if inTuple && target != "" && !strings.HasPrefix(target, "ufw-user-logging") {
+				tuples[len(tuples)-1] = append(tuples[len(tuples)-1], chainRef{chain: chain, index: index})
+			}
+		}
+	}
+	return tuples
+}
+
+func jumpTarget(fields []string) string {
+	for i, field := range fields {
+		if field == "-j" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// parseChainListing reads the counters from `iptables -L <chain> -v -n -x`.
+func parseChainListing(output string) []Counter {
+	var counters []Counter
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue // chain title and column headers
+		}
+		bytes, _ := strconv.ParseUint(fields[1], 10, 64)
+		counters = append(counters, Counter{Packets: packets, Bytes: bytes})
+	}
+	return counters
+}
+
+func sum(refs []chainRef, chains map[string][]Counter) Counter {
+	var total Counter
+	for _, ref := range refs {
+		if counters := chains[ref.chain]; ref.index < len(counters) {
+			total.Packets += counters[ref.index].Packets
+			total.Bytes += counters[ref.index].Bytes
+		}
+	}
+	return total
+}
@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/utils/oscmd"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const socketPath = "/var/run/docker.sock"
+
+// PublishedPort is a container port Docker exposes on the host. Docker
+// installs its own NAT and FORWARD rules for it, which run before ufw.
+type PublishedPort struct {
+	Container     string
+	ContainerIP   string
+	HostAddress   string
+	HostPort      string // port or range "5000-5001"
+	ContainerPort string
+	Protocol      string
+}
+
+// Detected reports whether Docker runs on this host, by its socket or its
+// iptables chains. The check runs once, menus and the status bar ask often.
+func Detected() bool {
+	return detected()
+}
+
+var detected = sync.OnceValue(func() bool {
+	if _, err := os.Stat(socketPath); err == nil {
+		return true
+	}
+	return exec.Command("sudo", "iptables", "-n", "-L", "DOCKER").Run() == nil
+})
+
+// LoadPublishedPorts lists the published ports of running containers.
+func LoadPublishedPorts() ([]PublishedPort, error) {
+	output, err := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Ports}}").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("docker ps: %s", strings.TrimSpace(string(output)))
+	}
+
+	var ports []PublishedPort
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		name, portList, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		ip := containerIP(name)
+		for _, port := range parsePorts(portList) {
+			port.Container = name
+			port.ContainerIP = ip
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// parsePorts reads the Ports column of `docker ps`, e.g.
+// "0.0.0.0:8080->80/tcp, :::8080->80/tcp, 5432/tcp". Ports that are not
+// published are skipped and IPv4/IPv6 duplicates are merged.
+func parsePorts(list string) []PublishedPort {
+	var ports []PublishedPort
+	seen := map[string]bool{}
+	for _, part := range strings.Split(list, ",") {
+		host, container, published := strings.Cut(strings.TrimSpace(part), "->")
+		if !published {
+			continue
+		}
+		containerPort, protocol, _ := strings.Cut(container, "/")
+		separator := strings.LastIndex(host, ":")
+		if separator == -1 {
+			continue
+		}
+		port := PublishedPort{
+			HostAddress:   host[:separator],
+			HostPort:      host[separator+1:],
+			ContainerPort: containerPort,
+			Protocol:      protocol,
+		}
+		key := port.HostPort + "/" + port.ContainerPort + "/" + port.Protocol
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+func containerIP(name string) string {
+	output := oscmd.RunCommand(fmt.Sprintf("docker inspect -f '{{range .NetworkSettings.Networks}}{{.IPAddress}} {{end}}' %q", name))
+	if strings.HasPrefix(output, "Error") {
+		return ""
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// AllowSpec is the ufw route rule letting outside traffic reach the port once
+// the DOCKER-USER integration is installed.
+func (p PublishedPort) AllowSpec() string {
+	return fmt.Sprintf("route allow proto %s from any to %s port %s comment '[docker:%s]'",
+		p.Protocol, p.ContainerIP, strings.ReplaceAll(p.ContainerPort, "-", ":"), p.Container)
+}
+
+// AllowingRules returns the route rules that let traffic reach the port.
+func (p PublishedPort) AllowingRules(rules []entity.Rule) []entity.Rule {
+	first, _, _ := strings.Cut(p.ContainerPort, "-")
+	port, err := strconv.Atoi(first)
+	if err != nil {
+		return nil
+	}
+
+	var allowing []entity.Rule
+	for _, rule := range rules {
+		if rule.Direction != "fwd" || !rule.IsAllowing() || rule.From.Interface != "" {
+			continue
+		}
+		if rule.To.Address != "" && rule.To.Address != p.ContainerIP {
+			continue
+		}
+		if rule.To.MatchesPort(port, p.Protocol, nil) {
+			allowing = append(allowing, rule)
+		}
+	}
+	return allowing
+}
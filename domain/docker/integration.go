@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"fwtui/domain/rulesfile"
+	"os/exec"
+	"strings"
+)
+
+const AfterRulesPath = "/etc/ufw/after.rules"
+
+const (
+	blockBegin = "# BEGIN UFW AND DOCKER"
+	blockEnd   = "# END UFW AND DOCKER"
+)
+
+// integrationBlock routes traffic to published ports through ufw's route
+// rules, the way ufw-docker does: private networks pass, new connections from
+// outside are dropped unless a "ufw route allow" rule accepts them.
+var integrationBlock = []string{
+	blockBegin,
+	"*filter",
+	":ufw-user-forward - [0:0]",
+	":ufw-docker-logging-deny - [0:0]",
+	":DOCKER-USER - [0:0]",
+	"-A DOCKER-USER -j ufw-user-forward",
+	"",
+	"-A DOCKER-USER -j RETURN -s 10.0.0.0/8",
+	"-A DOCKER-USER -j RETURN -s 172.16.0.0/12",
+	"-A DOCKER-USER -j RETURN -s 192.168.0.0/16",
+	"",
+	"-A DOCKER-USER -p udp -m udp --sport 53 --dport 1024:65535 -j RETURN",
+	"",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p tcp -m tcp --tcp-flags FIN,SYN,RST,ACK SYN -d 192.168.0.0/16",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p tcp -m tcp --tcp-flags FIN,SYN,RST,ACK SYN -d 10.0.0.0/8",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p tcp -m tcp --tcp-flags FIN,SYN,RST,ACK SYN -d 172.16.0.0/12",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p udp -m udp --dport 0:32767 -d 192.168.0.0/16",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p udp -m udp --dport 0:32767 -d 10.0.0.0/8",
+	"-A DOCKER-USER -j ufw-docker-logging-deny -p udp -m udp --dport 0:32767 -d 172.16.0.0/12",
+	"",
+	"-A DOCKER-USER -j RETURN",
+	"",
+	`-A ufw-docker-logging-deny -m limit --limit 3/min --limit-burst 10 -j LOG --log-prefix "[UFW DOCKER BLOCK] "`,
+	"-A ufw-docker-logging-deny -j DROP",
+	"",
+	"COMMIT",
+	blockEnd,
+}
+
+// IntegrationInstalled reports whether after.rules contains the DOCKER-USER block.
+func IntegrationInstalled() (bool, error) {
+	file, err := rulesfile.Load(AfterRulesPath)
+	if err != nil {
+		return false, err
+	}
+	start, _ := findBlock(file.Lines())
+	return start != -1, nil
+}
+
+// InstallIntegration appends the DOCKER-USER block to after.rules.
+func InstallIntegration() error {
+	file, err := rulesfile.Load(AfterRulesPath)
+	if err != nil {
+		return err
+	}
+	lines := file.Lines()
+	if start, _ := findBlock(lines); start != -1 {
+		return nil
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	lines = append(lines, "")
+	lines = append(lines, integrationBlock...)
+	file.SetLines(append(lines, ""))
+	return file.Save()
+}
+
+// RemoveIntegration deletes the DOCKER-USER block from after.rules.
+func RemoveIntegration() error {
+	file, err := rulesfile.Load(AfterRulesPath)
+	if err != nil {
+		return err
+	}
+	lines := file.Lines()
+	start, end := findBlock(lines)
+	if start == -1 {
+		return nil
+	}
+	file.SetLines(append(lines[:start:start], lines[end+1:]...))
+	if err := file.Save(); err != nil {
+		return err
+	}
+	// ufw does not flush chains it no longer declares
+	return exec.Command("sudo", "iptables", "-F", "DOCKER-USER").Run()
+}
+
+func findBlock(lines []string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case blockBegin:
+			start = i
+		case blockEnd:
+			if start != -1 {
+				return start, i
+			}
+		}
+	}
+	return -1, -1
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expiryLayout is the UTC timestamp stored in "[expires:...]" comment tags.
+const expiryLayout = "2006-01-02T15:04Z"
+
+var expiryTagRegex = regexp.MustCompile(`\[expires:([^\]]+)\]\s*`)
+
+// ExpiresAt returns the expiry time tagged in the rule's comment.
+func (r Rule) ExpiresAt() (time.Time, bool) {
+	match := expiryTagRegex.FindStringSubmatch(r.Comment)
+	if match == nil {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(expiryLayout, match[1])
+	return at, err == nil
+}
+
+func (r Rule) IsExpired(now time.Time) bool {
+	at, ok := r.ExpiresAt()
+	return ok && !now.Before(at)
+}
+
+// WithExpiry tags comment with an expiry time, replacing an existing tag.
+func WithExpiry(comment string, at time.Time) string {
+	tag := fmt.Sprintf("[expires:%s]", at.UTC().Format(expiryLayout))
+	return strings.TrimSpace(tag + " " + WithoutExpiry(comment))
+}
+
+// WithoutExpiry removes the expiry tag from comment.
+func WithoutExpiry(comment string) string {
+	return strings.TrimSpace(expiryTagRegex.ReplaceAllString(comment, ""))
+}
+
+// ParseExpiry reads a lifetime such as "90m", "4h" or "2d", or an absolute
+// local time such as "2025-06-01 18:00".
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if ttl, err := time.ParseDuration(s); err == nil {
+		if ttl <= 0 {
+			return time.Time{}, fmt.Errorf("lifetime must be positive: %s", s)
+		}
+		return now.Add(ttl), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if at, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			if !at.After(now) {
+				return time.Time{}, fmt.Errorf("expiry is in the past: %s", s)
+			}
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry: %s (use e.g. 4h, 2d or 2006-01-02 15:04)", s)
+}
+
+// FormatRemaining describes the lifetime left until at, e.g. "3h12m left".
+func FormatRemaining(at, now time.Time) string {
+	left := at.Sub(now)
+	switch {
+	case left <= 0:
+		return "expired"
+	case left >= 48*time.Hour:
+		return fmt.Sprintf("%dd left", int(left.Hours()/24))
+	case left >= time.Hour:
+		return fmt.Sprintf("%dh%02dm left", int(left.Hours()), int(left.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm left", int(left.Minutes())+1)
+}
@@ -2,33 +2,89 @@ package entity
 
 import (
 	"fmt"
+	"fwtui/domain/appfile"
 	"fwtui/domain/ufw"
+	"fwtui/utils/query"
 	"fwtui/utils/result"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/samber/lo"
 )
 
-const profilesPath = "/etc/ufw/applications.d/"
+const profilesPath = appfile.Dir
+
+// profileNameRegex is the charset ufw accepts for profile names. It keeps
+// names usable as file names in applications.d: no "/", brackets or control
+// characters, and no leading dot.
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.+-]*$`)
 
 type UFWProfile struct {
-	Name      string
-	Title     string
-	Ports     []string
-	Installed bool
+	Name        string
+	Title       string
+	Description string
+	Ports       []string
+	Installed   bool
+	File        string // file in applications.d defining the installed profile
+	Package     string // package owning File, empty for local profiles
+	Category    string // catalog group such as "Web" or "Databases", for profiles offered for installation
+}
+
+// ProfileExistsError reports a profile name already defined in applications.d.
+type ProfileExistsError struct {
+	Profile string
+	File    string
+}
+
+func (e ProfileExistsError) Error() string {
+	return fmt.Sprintf("profile %s already exists in %s", e.Profile, e.File)
+}
+
+// ValidateProfile rejects names that are no valid ufw profile name or file
+// name, and values that would break the INI file they are written to.
+func ValidateProfile(p UFWProfile) error {
+	if !profileNameRegex.MatchString(p.Name) || strings.Contains(p.Name, "..") {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, space, _ . + and -", p.Name)
+	}
+	for key, value := range map[string]string{
+		"title":       p.Title,
+		"description": p.Description,
+		"ports":       strings.Join(p.Ports, "|"),
+	} {
+		if strings.ContainsFunc(value, unicode.IsControl) {
+			return fmt.Errorf("invalid %s of profile %s: line breaks and control characters are not allowed", key, p.Name)
+		}
+	}
+	return nil
 }
 
 func CreateProfile(p UFWProfile) result.Result[string] {
+	if err := ValidateProfile(p); err != nil {
+		return result.Err[string](err)
+	}
+	path := filepath.Join(profilesPath, p.Name+".profile")
 	// check if file exists
-	if _, err := os.Stat(profilesPath + p.Name + ".profile"); !os.IsNotExist(err) {
-		return result.Err[string](fmt.Errorf("profile %s already exists", p.Name))
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return result.Err[string](ProfileExistsError{Profile: p.Name, File: path})
+	}
+	// or another file already defines a section of that name
+	if files, err := appfile.LoadDir(profilesPath); err == nil {
+		if file, ok := appfile.Find(files, p.Name); ok {
+			return result.Err[string](ProfileExistsError{Profile: p.Name, File: file.Path})
+		}
 	}
 
+	// ufw rejects profiles without a title or a description
+	title := lo.CoalesceOrEmpty(p.Title, p.Name)
+	description := lo.CoalesceOrEmpty(p.Description, title)
 	content := fmt.Sprintf("[%s]\ntitle=%s\ndescription=%s\nports=%s\n",
-		p.Name, p.Name, p.Title, strings.Join(p.Ports, "|"))
-	err := os.WriteFile(profilesPath+p.Name+".profile", []byte(content), 0644)
+		p.Name, title, description, strings.Join(p.Ports, "|"))
+	err := os.WriteFile(path, []byte(content), 0644)
 	if err != nil {
 		return result.Err[string](fmt.Errorf("error creating profile: %s", err))
 	}
@@ -36,147 +92,166 @@ func CreateProfile(p UFWProfile) result.Result[string] {
 	return result.Ok(fmt.Sprintf("Profile %s created", p.Name))
 }
 
-func DeleteProfile(p UFWProfile) string {
-	files, err := os.ReadDir(profilesPath)
+// PackageOwnedError reports a profile whose file belongs to a package. The
+// package manager may overwrite changes on upgrade, so callers confirm first.
+type PackageOwnedError struct {
+	Profile string
+	Package string
+	File    string
+}
+
+func (e PackageOwnedError) Error() string {
+	return fmt.Sprintf("profile %s is shipped by package %s in %s", e.Profile, e.Package, e.File)
+}
+
+// DeleteProfile removes the profile's section from its file, and the file once
+// it has no profile left. Package-owned files are only changed with force.
+func DeleteProfile(p UFWProfile, force bool) string {
+	file, err := profileFile(p.Name, force)
 	if err != nil {
-		return fmt.Sprintf("Error reading profiles directory: %s", err)
+		return "Error: " + err.Error()
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
+	if err := file.RemoveSection(p.Name); err != nil {
+		return "Error: " + err.Error()
+	}
+	if err := file.Save(); err != nil {
+		return fmt.Sprintf("Error deleting profile: %s", err)
+	}
+	return fmt.Sprintf("Profile '%s' deleted from %s", p.Name, file.Path)
+}
 
-		path := filepath.Join(profilesPath, file.Name())
-		content, err := os.ReadFile(path)
-		if err != nil {
-			continue // optionally log or handle read error
-		}
+// UpdateProfile rewrites the title, description and ports of an installed
+// profile in the file defining it and reloads it into ufw. Other sections and
+// keys of the file are kept. Package-owned files are only changed with force.
+func UpdateProfile(p UFWProfile, force bool) result.Result[string] {
+	if err := ValidateProfile(p); err != nil {
+		return result.Err[string](err)
+	}
+	file, err := profileFile(p.Name, force)
+	if err != nil {
+		return result.Err[string](err)
+	}
 
-		if strings.Contains(string(content), "["+p.Name+"]") {
-			err := os.Remove(path)
-			if err != nil {
-				return fmt.Sprintf("Error deleting profile: %s", err)
-			}
-			return fmt.Sprintf("Profile with title '%s' deleted", p.Name)
-		}
+	err = file.SetValues(p.Name, map[string]string{
+		"title":       p.Title,
+		"description": p.Description,
+		"ports":       strings.Join(p.Ports, "|"),
+	})
+	if err != nil {
+		return result.Err[string](err)
 	}
+	if err := file.Save(); err != nil {
+		return result.Err[string](fmt.Errorf("error writing profile: %s", err))
+	}
+	return result.Ok(ufw.LoadProfile(p.Name) + fmt.Sprintf("Profile %s updated in %s", p.Name, file.Path))
+}
 
-	return fmt.Sprintf("Profile with title '%s' not found", p.Title)
+func profileFile(name string, force bool) (*appfile.File, error) {
+	files, err := appfile.LoadDir(profilesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading profiles directory: %s", err)
+	}
+	file, ok := appfile.Find(files, name)
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found in %s", name, profilesPath)
+	}
+	if file.Package != "" && !force {
+		return nil, PackageOwnedError{Profile: name, Package: file.Package, File: file.Path}
+	}
+	return file, nil
 }
 
-func LoadInstalledProfiles() ([]UFWProfile, error) {
+// RulesUsingProfile returns the rules referring to the profile by name.
+func RulesUsingProfile(rules []Rule, name string) []Rule {
+	return lo.Filter(rules, func(rule Rule, _ int) bool {
+		return rule.To.App == name || rule.From.App == name
+	})
+}
 
-	profileNames := strings.Split(strings.TrimSpace(ufw.GetProfileList()), "\n")[1:]
+// LoadInstalledProfiles reads the profiles of every file in applications.d,
+// sorted by name like `ufw app list`.
+func LoadInstalledProfiles() ([]UFWProfile, error) {
+	files, err := appfile.LoadDir(profilesPath)
+	if err != nil {
+		return nil, err
+	}
 
 	var profiles []UFWProfile
-	for _, name := range profileNames {
-		name = strings.TrimSpace(name)
-		if name == "" {
-			continue
-		}
-		profile, err := getUFWProfileInfo(name)
-		if err != nil {
-			continue
+	for _, file := range files {
+		for _, section := range file.Sections {
+			profiles = append(profiles, UFWProfile{
+				Name:        section.Name,
+				Title:       section.Values["title"],
+				Description: section.Values["description"],
+				Ports:       strings.Split(section.Values["ports"], "|"),
+				Installed:   true,
+				File:        file.Path,
+				Package:     file.Package,
+			})
 		}
-		profiles = append(profiles, profile)
 	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
 	return profiles, nil
 }
 
-func getUFWProfileInfo(name string) (UFWProfile, error) {
-	lines := strings.Split(ufw.GetProfileInfo(name), "\n")
-
-	profile := UFWProfile{
-		Name:      name,
-		Installed: true,
-	}
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		switch {
-		case strings.HasPrefix(line, "Profile:"):
-			profile.Name = strings.TrimSpace(strings.TrimPrefix(line, "Profile:"))
-		case strings.HasPrefix(line, "Title:"):
-			profile.Title = strings.TrimSpace(strings.TrimPrefix(line, "Title:"))
-		case strings.HasPrefix(line, "Ports:") || strings.HasPrefix(line, "Port:"):
-			for j := i + 1; j < len(lines); j++ {
-				portLine := strings.TrimSpace(lines[j])
-				if portLine == "" {
-					break
+// FindProfileForPort returns the profile that covers the given port with the
+// fewest ports opened in total, preferring installed profiles.
+func FindProfileForPort(profiles []UFWProfile, port int, protocol string) (UFWProfile, bool) {
+	var best UFWProfile
+	bestSize := -1
+
+	for _, p := range profiles {
+		covered := false
+		size := 0
+		for _, spec := range p.Ports {
+			for _, r := range ParsePortSpec(spec) {
+				size += r.To - r.From + 1
+				if port >= r.From && port <= r.To && (r.Protocol == "" || r.Protocol == protocol) {
+					covered = true
 				}
-				profile.Ports = append(profile.Ports, portLine)
 			}
 		}
+		if !covered {
+			continue
+		}
+		if bestSize == -1 || size < bestSize || (size == bestSize && p.Installed && !best.Installed) {
+			best = p
+			bestSize = size
+		}
 	}
 
-	return profile, nil
+	return best, bestSize != -1
 }
 
-func InstallableProfiles() []UFWProfile {
-	installedProfiles, _ := LoadInstalledProfiles()
-	installedProfileNames := lo.Map(installedProfiles, func(p UFWProfile, _ int) string {
-		return p.Name
-	})
-
-	profiles := []UFWProfile{
-		// Common access
-		{Name: "OpenSSH", Title: "Secure shell access (SSH)", Ports: []string{"22/tcp"}, Installed: lo.Contains(installedProfileNames, "OpenSSH")},
-		{Name: "HTTP", Title: "Generic HTTP service", Ports: []string{"80/tcp"}, Installed: lo.Contains(installedProfileNames, "HTTP")},
-		{Name: "HTTPS", Title: "Generic HTTPS service", Ports: []string{"443/tcp"}, Installed: lo.Contains(installedProfileNames, "HTTPS")},
-
-		// Web servers
-		{Name: "Nginx HTTP", Title: "Nginx web server (HTTP only)", Ports: []string{"80/tcp"}, Installed: lo.Contains(installedProfileNames, "Nginx HTTP")},
-		{Name: "Nginx HTTPS", Title: "Nginx web server (HTTPS only)", Ports: []string{"443/tcp"}, Installed: lo.Contains(installedProfileNames, "Nginx HTTPS")},
-		{Name: "Nginx Full", Title: "Nginx web server (HTTP and HTTPS)", Ports: []string{"80,443/tcp"}, Installed: lo.Contains(installedProfileNames, "Nginx Full")},
-		{Name: "Apache", Title: "Apache web server (HTTP only)", Ports: []string{"80/tcp"}, Installed: lo.Contains(installedProfileNames, "Apache")},
-		{Name: "Apache Secure", Title: "Apache web server (HTTPS only)", Ports: []string{"443/tcp"}, Installed: lo.Contains(installedProfileNames, "Apache Secure")},
-		{Name: "Apache Full", Title: "Apache web server (HTTP and HTTPS)", Ports: []string{"80,443/tcp"}, Installed: lo.Contains(installedProfileNames, "Apache Full")},
-
-		// Databases
-		{Name: "PostgreSQL", Title: "PostgreSQL database server", Ports: []string{"5432/tcp"}, Installed: lo.Contains(installedProfileNames, "PostgreSQL")},
-		{Name: "MySQL", Title: "MySQL database server", Ports: []string{"3306/tcp"}, Installed: lo.Contains(installedProfileNames, "MySQL")},
-		{Name: "MongoDB", Title: "MongoDB database", Ports: []string{"27017/tcp"}, Installed: lo.Contains(installedProfileNames, "MongoDB")},
-		{Name: "Redis", Title: "Redis key-value store", Ports: []string{"6379/tcp"}, Installed: lo.Contains(installedProfileNames, "Redis")},
-		{Name: "InfluxDB", Title: "InfluxDB time series database", Ports: []string{"8086/tcp"}, Installed: lo.Contains(installedProfileNames, "InfluxDB")},
-		{Name: "Elasticsearch", Title: "Elasticsearch search engine", Ports: []string{"9200,9300/tcp"}, Installed: lo.Contains(installedProfileNames, "Elasticsearch")},
-
-		// DevOps / containers
-		{Name: "Docker Remote API", Title: "Docker remote API", Ports: []string{"2375,2376/tcp"}, Installed: lo.Contains(installedProfileNames, "Docker Remote API")},
-		{Name: "Kubernetes API", Title: "Kubernetes API server", Ports: []string{"6443/tcp"}, Installed: lo.Contains(installedProfileNames, "Kubernetes API")},
-		{Name: "Docker Swarm", Title: "Docker Swarm cluster communication", Ports: []string{"2377,7946/tcp", "7946,4789/udp"}, Installed: lo.Contains(installedProfileNames, "Docker Swarm")},
-
-		// VPN
-		{Name: "WireGuard", Title: "WireGuard VPN", Ports: []string{"51820/udp"}, Installed: lo.Contains(installedProfileNames, "WireGuard")},
-		{Name: "OpenVPN", Title: "OpenVPN", Ports: []string{"1194/udp"}, Installed: lo.Contains(installedProfileNames, "OpenVPN")},
-
-		// Email
-		{Name: "SMTP", Title: "Simple Mail Transfer Protocol", Ports: []string{"25/tcp"}, Installed: lo.Contains(installedProfileNames, "SMTP")},
-		{Name: "SMTPS", Title: "SMTP over SSL", Ports: []string{"465/tcp"}, Installed: lo.Contains(installedProfileNames, "SMTPS")},
-		{Name: "Submission", Title: "Mail Submission Agent", Ports: []string{"587/tcp"}, Installed: lo.Contains(installedProfileNames, "Submission")},
-		{Name: "IMAPS", Title: "IMAP over SSL", Ports: []string{"993/tcp"}, Installed: lo.Contains(installedProfileNames, "IMAPS")},
-		{Name: "POP3S", Title: "POP3 over SSL", Ports: []string{"995/tcp"}, Installed: lo.Contains(installedProfileNames, "POP3S")},
-
-		// DNS
-		{Name: "DNS", Title: "Domain name System", Ports: []string{"53/tcp", "53/udp"}, Installed: lo.Contains(installedProfileNames, "DNS")},
-
-		// File sharing
-		{Name: "Samba", Title: "Windows file/printer sharing (Samba)", Ports: []string{"137,138/udp", "139,445/tcp"}, Installed: lo.Contains(installedProfileNames, "Samba")},
-		{Name: "NFS", Title: "Network File System", Ports: []string{"111,2049/tcp", "111,2049/udp"}, Installed: lo.Contains(installedProfileNames, "NFS")},
-
-		// Misc
-		{Name: "CUPS", Title: "Common Unix Printing System", Ports: []string{"631/tcp"}, Installed: lo.Contains(installedProfileNames, "CUPS")},
-		{Name: "VNC", Title: "Virtual Network Computing (remote desktop)", Ports: []string{"5900/tcp"}, Installed: lo.Contains(installedProfileNames, "VNC")},
-		{Name: "Deluge", Title: "Deluge BitTorrent client", Ports: []string{"6881/tcp", "6881/udp"}, Installed: lo.Contains(installedProfileNames, "Deluge")},
-		{Name: "Prometheus", Title: "Prometheus monitoring", Ports: []string{"9090/tcp"}, Installed: lo.Contains(installedProfileNames, "Prometheus")},
-		{Name: "Grafana", Title: "Grafana dashboards", Ports: []string{"3000/tcp"}, Installed: lo.Contains(installedProfileNames, "Grafana")},
-		{Name: "RabbitMQ", Title: "RabbitMQ message broker", Ports: []string{"5672,15672/tcp"}, Installed: lo.Contains(installedProfileNames, "RabbitMQ")},
-		{Name: "Mosquitto", Title: "Mosquitto MQTT broker", Ports: []string{"1883,8883/tcp"}, Installed: lo.Contains(installedProfileNames, "Mosquitto")},
-	}
-
-	profiles = lo.Filter(profiles, func(p UFWProfile, _ int) bool {
-		return !p.Installed
-	})
-
-	return profiles
+// MatchesTerm reports whether the profile matches a filter term. Supported
+// fields are name, title, description, category and port.
+func (p UFWProfile) MatchesTerm(term query.Term) bool {
+	switch term.Field {
+	case "":
+		return query.ContainsFold(p.Name+" "+p.Title+" "+p.Description+" "+strings.Join(p.Ports, " "), term.Value)
+	case "name":
+		return query.ContainsFold(p.Name, term.Value)
+	case "title":
+		return query.ContainsFold(p.Title, term.Value)
+	case "description":
+		return query.ContainsFold(p.Description, term.Value)
+	case "category":
+		return query.ContainsFold(p.Category, term.Value)
+	case "port":
+		port, err := strconv.Atoi(term.Value)
+		if err != nil {
+			return query.ContainsFold(strings.Join(p.Ports, " "), term.Value)
+		}
+		for _, spec := range p.Ports {
+			for _, r := range ParsePortSpec(spec) {
+				if port >= r.From && port <= r.To {
+					return true
+				}
+			}
+		}
+	}
+	return false
 }
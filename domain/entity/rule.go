@@ -0,0 +1,352 @@
+package entity
+
+import (
+	"fwtui/utils/netext"
+	"fwtui/utils/query"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Endpoint is one side (To or From) of a rule as printed by `ufw status`.
+type Endpoint struct {
+	Address   string // IP or CIDR, empty means anywhere
+	Ports     string // e.g. "22", "80,443", "6000:6007", empty means any port
+	Protocol  string // "tcp", "udp", ..., empty means any protocol
+	App       string // application profile name
+	Interface string
+}
+
+type Rule struct {
+	Number    int
+	Action    string // allow, deny, reject, limit
+	Direction string // in, out, fwd
+	To        Endpoint
+	From      Endpoint
+	Comment   string
+	V6        bool
+	Line      string
+	Log       string // "log" or "log-all", empty when the rule does not log
+	Disabled  bool   // parked by fwtui, not active in ufw
+}
+
+type PortRange struct {
+	From     int
+	To       int
+	Protocol string // empty means any protocol
+}
+
+var (
+	numberedRuleRegex = regexp.MustCompile(`^\[\s*(\d+)\]\s+(.+?)\s+(ALLOW|DENY|REJECT|LIMIT)(?:\s+(IN|OUT|FWD))?\s+(.*)$`)
+	ruleAttribsRegex  = regexp.MustCompile(`\s*\(((?:log-all|log|out)(?:,\s*(?:log-all|log|out))*)\)$`)
+	portSpecRegex     = regexp.MustCompile(`^[0-9][0-9,:]*(/[a-z]+)?$`)
+)
+
+// ParseRules parses the output of `ufw status numbered`.
+// Example line: "[ 1] 22/tcp                     ALLOW IN    Anywhere                   # ssh"
+func ParseRules(statusNumbered string) []Rule {
+	var rules []Rule
+	for _, line := range strings.Split(statusNumbered, "\n") {
+		rule, ok := parseRuleLine(strings.TrimSpace(line))
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseRuleLine(line string) (Rule, bool) {
+	match := numberedRuleRegex.FindStringSubmatch(line)
+	if match == nil {
+		return Rule{}, false
+	}
+
+	number, _ := strconv.Atoi(match[1])
+	rule := Rule{
+		Number:    number,
+		Action:    strings.ToLower(match[3]),
+		Direction: strings.ToLower(match[4]),
+		Line:      line,
+	}
+	if rule.Direction == "" {
+		rule.Direction = "in"
+	}
+
+	from := match[5]
+	if idx := strings.Index(from, "# "); idx != -1 {
+		rule.Comment = strings.TrimSpace(from[idx+2:])
+		from = from[:idx]
+	}
+	from = strings.TrimSpace(from)
+	if attribs := ruleAttribsRegex.FindStringSubmatch(from); attribs != nil {
+		for _, attrib := range strings.Split(attribs[1], ",") {
+			if attrib = strings.TrimSpace(attrib); strings.HasPrefix(attrib, "log") {
+				rule.Log = attrib
+			}
+		}
+		from = strings.TrimSpace(from[:len(from)-len(attribs[0])])
+	}
+
+	var toV6, fromV6 bool
+	rule.To, toV6 = parseEndpoint(match[2])
+	rule.From, fromV6 = parseEndpoint(from)
+	rule.V6 = toV6 || fromV6
+
+	return rule, true
+}
+
+func parseEndpoint(text string) (Endpoint, bool) {
+	var endpoint Endpoint
+	text = strings.TrimSpace(text)
+
+	v6 := strings.HasSuffix(text, "(v6)")
+	text = strings.TrimSpace(strings.TrimSuffix(text, "(v6)"))
+
+	if idx := strings.Index(text, " on "); idx != -1 {
+		endpoint.Interface = strings.TrimSpace(text[idx+4:])
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	if text == "Anywhere" || text == "" {
+		return endpoint, v6
+	}
+
+	fields := strings.Fields(text)
+	if _, err := netext.ParseIPOrCIDR(fields[0]); err == nil {
+		endpoint.Address = fields[0]
+		fields = fields[1:]
+	}
+
+	rest := strings.Join(fields, " ")
+	switch {
+	case rest == "":
+	case portSpecRegex.MatchString(rest):
+		endpoint.Ports, endpoint.Protocol, _ = strings.Cut(rest, "/")
+	default:
+		endpoint.App = rest
+	}
+
+	return endpoint, v6
+}
+
+// AnyPort reports whether the endpoint matches every port.
+func (e Endpoint) AnyPort() bool {
+	return e.Ports == "" && e.App == ""
+}
+
+// PortRanges returns the ports covered by the endpoint. Application profiles
+// are resolved through apps, a map of profile name to its port specs.
+func (e Endpoint) PortRanges(apps map[string][]string) []PortRange {
+	if e.App != "" {
+		var ranges []PortRange
+		for _, spec := range apps[e.App] {
+			ranges = append(ranges, ParsePortSpec(spec)...)
+		}
+		return ranges
+	}
+	if e.Ports == "" {
+		return nil
+	}
+	spec := e.Ports
+	if e.Protocol != "" {
+		spec += "/" + e.Protocol
+	}
+	return ParsePortSpec(spec)
+}
+
+// MatchesPort reports whether the endpoint covers the given port and protocol.
+func (e Endpoint) MatchesPort(port int, protocol string, apps map[string][]string) bool {
+	if e.AnyPort() {
+		return e.Protocol == "" || e.Protocol == protocol
+	}
+	for _, r := range e.PortRanges(apps) {
+		if port >= r.From && port <= r.To && (r.Protocol == "" || r.Protocol == protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePortSpec parses specs such as "22", "80,443/tcp" or "6000:6007/udp".
+func ParsePortSpec(spec string) []PortRange {
+	portList, protocol, _ := strings.Cut(strings.TrimSpace(spec), "/")
+
+	var ranges []PortRange
+	for _, part := range strings.Split(portList, ",") {
+		start, end, isRange := strings.Cut(strings.TrimSpace(part), ":")
+		from, err := strconv.Atoi(start)
+		if err != nil {
+			continue
+		}
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(end)
+			if err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, PortRange{From: from, To: to, Protocol: protocol})
+	}
+	return ranges
+}
+
+// IsAllowing reports whether matching traffic is accepted (limit accepts until the rate is exceeded).
+func (r Rule) IsAllowing() bool {
+	return r.Action == "allow" || r.Action == "limit"
+}
+
+// ProfilePorts maps installed profile names to their port specs.
+func ProfilePorts(profiles []UFWProfile) map[string][]string {
+	apps := make(map[string][]string, len(profiles))
+	for _, p := range profiles {
+		apps[p.Name] = p.Ports
+	}
+	return apps
+}
+
+// MatchesTerm reports whether the rule matches a filter term. Supported fields
+// are port, from, to, action, proto, on (interface), comment and tag; addresses
+// match when the networks overlap, e.g. "from:10.20.0.0/16".
+func (r Rule) MatchesTerm(term query.Term) bool {
+	switch term.Field {
+	case "":
+		return query.ContainsFold(r.Line, term.Value)
+	case "port":
+		port, err := strconv.Atoi(term.Value)
+		if err != nil {
+			return query.ContainsFold(r.To.Ports+" "+r.To.App+" "+r.From.Ports, term.Value)
+		}
+		return (!r.To.AnyPort() && r.To.MatchesPort(port, r.To.Protocol, nil)) ||
+			(!r.From.AnyPort() && r.From.MatchesPort(port, r.From.Protocol, nil))
+	case "from":
+		return matchesAddress(r.From.Address, term.Value)
+	case "to":
+		return matchesAddress(r.To.Address, term.Value)
+	case "action":
+		return strings.HasPrefix(r.Action, strings.ToLower(term.Value))
+	case "proto":
+		return strings.EqualFold(r.To.Protocol, term.Value) || strings.EqualFold(r.From.Protocol, term.Value)
+	case "on":
+		return query.ContainsFold(r.To.Interface+" "+r.From.Interface, term.Value)
+	case "comment":
+		return query.ContainsFold(r.Comment, term.Value)
+	case "tag":
+		return r.matchesTag(term.Value)
+	}
+	return false
+}
+
+func matchesAddress(address, value string) bool {
+	if strings.EqualFold(value, "any") || strings.EqualFold(value, "anywhere") {
+		return address == ""
+	}
+
+	want, err := netext.ParseIPOrCIDR(value)
+	if err != nil {
+		return query.ContainsFold(address, value)
+	}
+	if address == "" {
+		return false
+	}
+	have, err := netext.ParseIPOrCIDR(address)
+	return err == nil && netext.Overlaps(have, want)
+}
+
+// PortLabel renders the destination ports as shown in the rules table, e.g. "80,443/tcp".
+func (r Rule) PortLabel() string {
+	switch {
+	case r.To.App != "":
+		return r.To.App
+	case r.To.Ports == "":
+		return lo.Ternary(r.To.Protocol == "", "any", "any/"+r.To.Protocol)
+	case r.To.Protocol == "":
+		return r.To.Ports
+	}
+	return r.To.Ports + "/" + r.To.Protocol
+}
+
+func (r Rule) IPVersion() string {
+	return lo.Ternary(r.V6, "v6", "v4")
+}
+
+// Spec rebuilds the rule in ufw's full syntax, e.g.
+// "allow in on eth0 proto tcp from 10.0.0.0/8 to any port 22 comment 'ssh'".
+func (r Rule) Spec() string {
+	var parts []string
+	if r.Direction == "fwd" {
+		// status lists the incoming interface with the source, e.g.
+		// "Anywhere on eth1  ALLOW FWD  Anywhere on eth0" is "in on eth0 out on eth1"
+		parts = append(parts, "route", r.Action)
+		if r.From.Interface != "" {
+			parts = append(parts, "in", "on", r.From.Interface)
+		}
+		if r.To.Interface != "" {
+			parts = append(parts, "out", "on", r.To.Interface)
+		}
+	} else {
+		parts = append(parts, r.Action, r.Direction)
+		if r.Direction == "in" && r.To.Interface != "" {
+			parts = append(parts, "on", r.To.Interface)
+		}
+		if r.Direction == "out" && r.From.Interface != "" {
+			parts = append(parts, "on", r.From.Interface)
+		}
+	}
+	if r.Log != "" {
+		parts = append(parts, r.Log)
+	}
+
+	protocol := lo.CoalesceOrEmpty(r.To.Protocol, r.From.Protocol)
+	if protocol != "" {
+		parts = append(parts, "proto", protocol)
+	}
+
+	parts = append(parts, "from", lo.CoalesceOrEmpty(r.From.Address, "any"))
+	if r.From.Ports != "" {
+		parts = append(parts, "port", r.From.Ports)
+	}
+	if r.From.App != "" {
+		parts = append(parts, "app", shellQuote(r.From.App))
+	}
+
+	parts = append(parts, "to", lo.CoalesceOrEmpty(r.To.Address, "any"))
+	if r.To.Ports != "" {
+		parts = append(parts, "port", r.To.Ports)
+	}
+	if r.To.App != "" {
+		parts = append(parts, "app", shellQuote(r.To.App))
+	}
+
+	if r.Comment != "" {
+		parts = append(parts, "comment", shellQuote(r.Comment))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// IsTwinOf reports whether other is the same rule for the other IP version,
+// as ufw lists "allow 22/tcp" once for IPv4 and once for IPv6.
+func (r Rule) IsTwinOf(other Rule) bool {
+	return r.V6 != other.V6 &&
+		r.To.Address == "" && other.To.Address == "" &&
+		r.From.Address == "" && other.From.Address == "" &&
+		r.Spec() == other.Spec()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, `'`, `'\''`) + "'"
+}
+
+// Overlaps reports whether the two ranges share a port and a protocol.
+func (p PortRange) Overlaps(other PortRange) bool {
+	return p.From <= other.To && other.From <= p.To &&
+		(p.Protocol == "" || other.Protocol == "" || p.Protocol == other.Protocol)
+}
+
+// Covers reports whether every port and protocol of other is inside p.
+func (p PortRange) Covers(other PortRange) bool {
+	return p.From <= other.From && other.To <= p.To &&
+		(p.Protocol == "" || p.Protocol == other.Protocol)
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     Rule
+		wantSpec string
+	}{
+		{
+			line:     "[ 1] 22/tcp                     ALLOW IN    Anywhere                   # ssh",
+			want:     Rule{Number: 1, Action: "allow", Direction: "in", To: Endpoint{Ports: "22", Protocol: "tcp"}, Comment: "ssh"},
+			wantSpec: "allow in proto tcp from any to any port 22 comment 'ssh'",
+		},
+		{
+			line:     "[ 2] 80,443/tcp                 ALLOW IN    10.0.0.0/8",
+			want:     Rule{Number: 2, Action: "allow", Direction: "in", To: Endpoint{Ports: "80,443", Protocol: "tcp"}, From: Endpoint{Address: "10.0.0.0/8"}},
+			wantSpec: "allow in proto tcp from 10.0.0.0/8 to any port 80,443",
+		},
+		{
+			line:     "[ 3] Anywhere on eth1           DENY IN     192.168.1.5",
+			want:     Rule{Number: 3, Action: "deny", Direction: "in", To: Endpoint{Interface: "eth1"}, From: Endpoint{Address: "192.168.1.5"}},
+			wantSpec: "deny in on eth1 from 192.168.1.5 to any",
+		},
+		{
+			line:     "[ 4] 10.0.0.5 80/tcp            ALLOW FWD   Anywhere on eth0           # port forward 8080",
+			want:     Rule{Number: 4, Action: "allow", Direction: "fwd", To: Endpoint{Address: "10.0.0.5", Ports: "80", Protocol: "tcp"}, From: Endpoint{Interface: "eth0"}, Comment: "port forward 8080"},
+			wantSpec: "route allow in on eth0 proto tcp from any to 10.0.0.5 port 80 comment 'port forward 8080'",
+		},
+		{
+			line:     "[ 5] Anywhere on eth1           ALLOW FWD   Anywhere on eth0",
+			want:     Rule{Number: 5, Action: "allow", Direction: "fwd", To: Endpoint{Interface: "eth1"}, From: Endpoint{Interface: "eth0"}},
+			wantSpec: "route allow in on eth0 out on eth1 from any to any",
+		},
+		{
+			line:     "[ 6] 8.8.8.8 53/udp             ALLOW OUT   Anywhere on eth0 (out)",
+			want:     Rule{Number: 6, Action: "allow", Direction: "out", To: Endpoint{Address: "8.8.8.8", Ports: "53", Protocol: "udp"}, From: Endpoint{Interface: "eth0"}},
+			wantSpec: "allow out on eth0 proto udp from any to 8.8.8.8 port 53",
+		},
+		{
+			line:     "[ 7] Nginx Full                 ALLOW IN    Anywhere                   (log)",
+			want:     Rule{Number: 7, Action: "allow", Direction: "in", To: Endpoint{App: "Nginx Full"}, Log: "log"},
+			wantSpec: "allow in log from any to any app 'Nginx Full'",
+		},
+		{
+			line:     "[ 8] 22/tcp (v6)                LIMIT IN    Anywhere (v6)              (log-all)",
+			want:     Rule{Number: 8, Action: "limit", Direction: "in", To: Endpoint{Ports: "22", Protocol: "tcp"}, Log: "log-all", V6: true},
+			wantSpec: "limit in log-all proto tcp from any to any port 22",
+		},
+		{
+			line:     "[ 9] 2001:db8::/32 6000:6007/tcp DENY IN    Anywhere (v6)",
+			want:     Rule{Number: 9, Action: "deny", Direction: "in", To: Endpoint{Address: "2001:db8::/32", Ports: "6000:6007", Protocol: "tcp"}, V6: true},
+			wantSpec: "deny in proto tcp from any to 2001:db8::/32 port 6000:6007",
+		},
+		{
+			line:     "[10] Anywhere                   REJECT OUT  10.0.0.0/8 1234/udp (log, out)",
+			want:     Rule{Number: 10, Action: "reject", Direction: "out", From: Endpoint{Address: "10.0.0.0/8", Ports: "1234", Protocol: "udp"}, Log: "log"},
+			wantSpec: "reject out log proto udp from 10.0.0.0/8 port 1234 to any",
+		},
+		{
+			line:     "[11] 443                        ALLOW IN    Anywhere                   # it's [expires:2026-10-20T00:00:00Z]",
+			want:     Rule{Number: 11, Action: "allow", Direction: "in", To: Endpoint{Ports: "443"}, Comment: "it's [expires:2026-10-20T00:00:00Z]"},
+			wantSpec: `allow in from any to any port 443 comment 'it'\''s [expires:2026-10-20T00:00:00Z]'`,
+		},
+	}
+
+	var lines []string
+	for _, tt := range tests {
+		lines = append(lines, tt.line)
+	}
+	status := "Status: active\n\n     To                         Action      From\n     --                         ------      ----\n" + strings.Join(lines, "\n") + "\n"
+	rules := ParseRules(status)
+	if len(rules) != len(tests) {
+		t.Fatalf("got %d rules, want %d", len(rules), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.line[:4], func(t *testing.T) {
+			want := tt.want
+			want.Line = tt.line
+			if !reflect.DeepEqual(rules[i], want) {
+				t.Errorf("got  %+v\nwant %+v", rules[i], want)
+			}
+			if spec := rules[i].Spec(); spec != tt.wantSpec {
+				t.Errorf("spec: got %q, want %q", spec, tt.wantSpec)
+			}
+		})
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   Endpoint
+		wantV6 bool
+	}{
+		{"Anywhere", Endpoint{}, false},
+		{"Anywhere (v6)", Endpoint{}, true},
+		{"22", Endpoint{Ports: "22"}, false},
+		{"22/tcp (v6)", Endpoint{Ports: "22", Protocol: "tcp"}, true},
+		{"10.0.0.0/8 on eth0", Endpoint{Address: "10.0.0.0/8", Interface: "eth0"}, false},
+		{"fe80::1 546/udp", Endpoint{Address: "fe80::1", Ports: "546", Protocol: "udp"}, false},
+		{"OpenSSH (v6)", Endpoint{App: "OpenSSH"}, true},
+		{"192.168.1.0/24 Apache Full", Endpoint{Address: "192.168.1.0/24", App: "Apache Full"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, v6 := parseEndpoint(tt.text)
+			if got != tt.want || v6 != tt.wantV6 {
+				t.Errorf("got %+v, %v, want %+v, %v", got, v6, tt.want, tt.wantV6)
+			}
+		})
+	}
+}
+
+func TestParseRuleLineSkipsOtherLines(t *testing.T) {
+	for _, line := range []string{
+		"Status: active",
+		"To                         Action      From",
+		"--                         ------      ----",
+		"",
+		"22/tcp                     ALLOW       Anywhere",
+	} {
+		if rule, ok := parseRuleLine(line); ok {
+			t.Errorf("%q parsed as %+v", line, rule)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"fmt"
+	"fwtui/domain/ufw"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// DeleteRules deletes the rules from ufw. Deleting shifts the numbers of the
+// rules after it, so the highest number goes first.
+func DeleteRules(rules []Rule) string {
+	numbers := lo.Uniq(lo.Map(rules, func(rule Rule, _ int) int {
+		return rule.Number
+	}))
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+
+	var output string
+	for _, number := range numbers {
+		output += ufw.DeleteRuleByNumber(number)
+	}
+	return output
+}
+
+// InsertOrAppend inserts the rule at position. ufw rejects positions past the
+// end, so those append instead.
+func InsertOrAppend(position int, spec string) string {
+	if position > len(ParseRules(ufw.StatusNumbered())) {
+		return ufw.AddRule(spec)
+	}
+	return ufw.InsertRule(position, spec)
+}
+
+// Failed reports whether a ufw command printed an error.
+func Failed(output string) bool {
+	return strings.Contains(output, "ERROR") || strings.HasPrefix(output, "Error")
+}
+
+// RestoreRule adds a deleted rule again, at its old number when ufw still
+// accepts that position and appended otherwise. The output ends with an error
+// naming the command to run by hand when the rule could not be added at all.
+func RestoreRule(rule Rule) (string, bool) {
+	output := InsertOrAppend(rule.Number, rule.Spec())
+	if !Failed(output) {
+		return output, true
+	}
+	// an IPv6 rule cannot go in front of IPv4 rules, and numbers may have shifted
+	appended := ufw.AddRule(rule.Spec())
+	output += appended
+	if !Failed(appended) {
+		return output, true
+	}
+	return output + fmt.Sprintf("Error: rule [%d] was deleted and could not be restored, add it again with: sudo ufw %s\n", rule.Number, rule.Spec()), false
+}
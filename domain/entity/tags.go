@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tag is a "[key:value]" marker in a rule comment, the only metadata ufw keeps
+// with a rule. Rules sharing a tag form a group, e.g. "[team:web] nginx".
+type Tag struct {
+	Key   string
+	Value string
+}
+
+var tagRegex = regexp.MustCompile(`\[([A-Za-z][A-Za-z0-9_-]*):([^\]]+)\]`)
+
+func (t Tag) String() string {
+	return "[" + t.Key + ":" + t.Value + "]"
+}
+
+// ParseTags returns the tags in a comment in order of appearance.
+func ParseTags(comment string) []Tag {
+	var tags []Tag
+	for _, match := range tagRegex.FindAllStringSubmatch(comment, -1) {
+		tags = append(tags, Tag{Key: strings.ToLower(match[1]), Value: strings.TrimSpace(match[2])})
+	}
+	return tags
+}
+
+// GroupTags returns the tags used for grouping; the expiry tag is excluded as
+// every temporary rule has a different one.
+func (r Rule) GroupTags() []Tag {
+	var tags []Tag
+	for _, tag := range ParseTags(r.Comment) {
+		if tag.Key != "expires" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (r Rule) HasTag(tag Tag) bool {
+	for _, t := range r.GroupTags() {
+		if t.Key == tag.Key && strings.EqualFold(t.Value, tag.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTag matches "team:web" exactly, or "team" and "web" against any tag key or value.
+func (r Rule) matchesTag(value string) bool {
+	key, tagValue, exact := strings.Cut(value, ":")
+	for _, tag := range r.GroupTags() {
+		if exact {
+			if strings.EqualFold(tag.Key, key) && strings.EqualFold(tag.Value, tagValue) {
+				return true
+			}
+		} else if strings.EqualFold(tag.Key, value) || strings.EqualFold(tag.Value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+type RuleGroup struct {
+	Tag   Tag
+	Rules []Rule
+}
+
+// GroupRules groups rules by tag, sorted by tag. A rule with several tags
+// appears in several groups.
+func GroupRules(rules []Rule) []RuleGroup {
+	byTag := map[Tag][]Rule{}
+	for _, rule := range rules {
+		for _, tag := range rule.GroupTags() {
+			tag.Value = strings.ToLower(tag.Value)
+			byTag[tag] = append(byTag[tag], rule)
+		}
+	}
+
+	groups := make([]RuleGroup, 0, len(byTag))
+	for tag, tagged := range byTag {
+		groups = append(groups, RuleGroup{Tag: tag, Rules: tagged})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Tag.String() < groups[j].Tag.String()
+	})
+	return groups
+}
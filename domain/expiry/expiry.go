@@ -0,0 +1,37 @@
+package expiry
+
+import (
+	"fwtui/domain/entity"
+	"fwtui/domain/parked"
+	"fwtui/domain/ufw"
+	"time"
+)
+
+// RemoveExpired deletes every rule whose expiry tag has passed, including
+// disabled ones, and returns the removed rules together with the ufw output.
+func RemoveExpired(now time.Time) ([]entity.Rule, string) {
+	var expired []entity.Rule
+	for _, rule := range entity.ParseRules(ufw.StatusNumbered()) {
+		if rule.IsExpired(now) {
+			expired = append(expired, rule)
+		}
+	}
+	output := entity.DeleteRules(expired)
+
+	parkedRules, err := parked.Load()
+	if err != nil {
+		return expired, output + "Error: " + err.Error() + "\n"
+	}
+	var expiredParked []parked.ParkedRule
+	for _, rule := range parked.Rules(parkedRules) {
+		if rule.IsExpired(now) {
+			p, _ := parked.Find(parkedRules, rule)
+			expiredParked = append(expiredParked, p)
+			expired = append(expired, rule)
+		}
+	}
+	if len(expiredParked) > 0 {
+		output += parked.Remove(expiredParked)
+	}
+	return expired, output
+}
@@ -0,0 +1,176 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"fmt"
+	"fwtui/utils/netext"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadRangeCSV reads DB-IP style "first,last,country" rows.
+func loadRangeCSV(path string) (map[string]*Country, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	countries := map[string]*Country{}
+	reader := newCSVReader(file)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) < 3 {
+			continue
+		}
+		start, err1 := netip.ParseAddr(strings.TrimSpace(row[0]))
+		end, err2 := netip.ParseAddr(strings.TrimSpace(row[1]))
+		code := strings.ToUpper(strings.TrimSpace(row[2]))
+		if err1 != nil || err2 != nil || len(code) != 2 || code == "ZZ" {
+			continue // header or unassigned range
+		}
+		country := countryFor(countries, code, "")
+		country.Prefixes = append(country.Prefixes, netext.RangeToPrefixes(start, end)...)
+	}
+	return countries, nil
+}
+
+// loadMaxMindCSV reads the GeoLite2-Country CSV edition: Blocks-IPv4/IPv6 map
+// networks to a geoname_id, Locations-en maps the id to the country.
+func loadMaxMindCSV(dir string) (map[string]*Country, error) {
+	locations, err := findFile(dir, "Locations-en.csv")
+	if err != nil {
+		return nil, err
+	}
+	names, err := readLocations(locations)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := map[string]*Country{}
+	for _, suffix := range []string{"Blocks-IPv4.csv", "Blocks-IPv6.csv"} {
+		blocks, err := findFile(dir, suffix)
+		if err != nil {
+			return nil, err
+		}
+		if err := readBlocks(blocks, names, countries); err != nil {
+			return nil, err
+		}
+	}
+	return countries, nil
+}
+
+func findFile(dir, suffix string) (string, error) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+suffix))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no *%s in %s", suffix, dir)
+	}
+	return matches[0], nil
+}
+
+// readLocations maps geoname_id to the country code and name.
+func readLocations(path string) (map[string][2]string, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	id, code, name := column(rows, "geoname_id"), column(rows, "country_iso_code"), column(rows, "country_name")
+	if id == -1 || code == -1 {
+		return nil, fmt.Errorf("%s: missing geoname_id or country_iso_code column", filepath.Base(path))
+	}
+
+	names := map[string][2]string{}
+	for _, row := range rows[1:] {
+		if len(row) <= code || row[code] == "" {
+			continue // continents without a country
+		}
+		names[row[id]] = [2]string{row[code], cell(row, name)}
+	}
+	return names, nil
+}
+
+func readBlocks(path string, names map[string][2]string, countries map[string]*Country) error {
+	rows, err := readCSV(path)
+	if err != nil {
+		return err
+	}
+	network, id, registered := column(rows, "network"), column(rows, "geoname_id"), column(rows, "registered_country_geoname_id")
+	if network == -1 || id == -1 {
+		return fmt.Errorf("%s: missing network or geoname_id column", filepath.Base(path))
+	}
+
+	for _, row := range rows[1:] {
+		prefix, err := netip.ParsePrefix(cell(row, network))
+		if err != nil {
+			continue
+		}
+		location, ok := names[cell(row, id)]
+		if !ok {
+			location, ok = names[cell(row, registered)]
+		}
+		if !ok {
+			continue
+		}
+		country := countryFor(countries, location[0], location[1])
+		country.Prefixes = append(country.Prefixes, prefix)
+	}
+	return nil
+}
+
+func countryFor(countries map[string]*Country, code, name string) *Country {
+	country, ok := countries[code]
+	if !ok {
+		country = &Country{Code: code, Name: name}
+		countries[code] = country
+	}
+	return country
+}
+
+func readCSV(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rows, err := newCSVReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s is empty", filepath.Base(path))
+	}
+	return rows, nil
+}
+
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// column returns the index of name in the header row, or -1.
+func column(rows [][]string, name string) int {
+	for i, header := range rows[0] {
+		if strings.TrimSpace(header) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cell returns the value at index i, or "" when the row is short.
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
@@ -0,0 +1,246 @@
+package geoip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fwtui/domain/ipset"
+	"fwtui/domain/state"
+	"fwtui/domain/ufw"
+	"fwtui/utils/netext"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Mode string
+
+const (
+	ModeAllow Mode = "allow" // only the selected countries may connect
+	ModeDeny  Mode = "deny"  // the selected countries are dropped
+)
+
+const (
+	storeFile = "geoip.json"
+	setName   = "fwtui-geo"
+)
+
+// DefaultDatabases are the places GeoIP databases are usually installed by
+// geoipupdate or distribution packages.
+var DefaultDatabases = []string{
+	"/usr/share/GeoIP/GeoLite2-Country.mmdb",
+	"/var/lib/GeoIP/GeoLite2-Country.mmdb",
+	"/usr/share/GeoIP/dbip-country-lite.mmdb",
+	"/usr/share/GeoIP/dbip-country-lite.csv",
+}
+
+// privateNetworks are always allowed in allow mode, so the host keeps talking
+// to itself and its local networks.
+var privateNetworks = []string{
+	"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16",
+	"::1/128", "fc00::/7", "fe80::/10",
+}
+
+type Country struct {
+	Code     string
+	Name     string
+	Prefixes []netip.Prefix
+}
+
+func (c Country) Label() string {
+	if c.Name == "" {
+		return c.Code
+	}
+	return fmt.Sprintf("%s  %s", c.Code, c.Name)
+}
+
+// Config is the applied country restriction, kept to refresh it when the
+// database is updated.
+type Config struct {
+	Database  string    `json:"database"`
+	Mode      Mode      `json:"mode"`
+	Countries []string  `json:"countries"`
+	Protocol  string    `json:"protocol,omitempty"`
+	Port      string    `json:"port,omitempty"` // empty restricts every port
+	Networks  int       `json:"networks"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultDatabase returns the first installed database, if any.
+func DefaultDatabase() string {
+	for _, path := range DefaultDatabases {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads the countries of a MaxMind or DB-IP database: an .mmdb file, a
+// DB-IP style "start,end,country" CSV or a directory holding MaxMind's
+// GeoLite2-Country CSV files. Countries are sorted by code.
+func Load(path string) ([]Country, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var countries map[string]*Country
+	switch {
+	case info.IsDir():
+		countries, err = loadMaxMindCSV(path)
+	case strings.HasSuffix(path, ".mmdb"):
+		countries, err = loadMMDB(path)
+	case strings.HasSuffix(path, ".csv"):
+		countries, err = loadRangeCSV(path)
+	default:
+		return nil, fmt.Errorf("unknown database format %s, expected .mmdb, .csv or a directory of GeoLite2 CSV files", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(countries) == 0 {
+		return nil, fmt.Errorf("no countries found in %s", path)
+	}
+
+	result := make([]Country, 0, len(countries))
+	for _, country := range countries {
+		country.Prefixes = netext.AggregatePrefixes(country.Prefixes)
+		result = append(result, *country)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result, nil
+}
+
+func LoadConfig() (*Config, error) {
+	data, err := state.ReadFile(storeFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading geoip config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing geoip config: %w", err)
+	}
+	return &config, nil
+}
+
+func saveConfig(config Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFile(storeFile, data)
+}
+
+// Outdated reports whether the database changed since the config was applied.
+func (c Config) Outdated() bool {
+	info, err := os.Stat(c.Database)
+	return err == nil && info.ModTime().After(c.UpdatedAt)
+}
+
+func (c Config) Validate() error {
+	if len(c.Countries) == 0 {
+		return fmt.Errorf("select at least one country")
+	}
+	if c.Mode != ModeAllow && c.Mode != ModeDeny {
+		return fmt.Errorf("unknown mode %s", c.Mode)
+	}
+	if c.Port != "" {
+		port, err := strconv.Atoi(c.Port)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %s", c.Port)
+		}
+		if c.Protocol != "tcp" && c.Protocol != "udp" {
+			return fmt.Errorf("a port needs the protocol tcp or udp")
+		}
+	}
+	return nil
+}
+
+// Apply builds the set of the selected countries from the database and drops
+// traffic in before.rules: from the countries in deny mode, from everywhere
+// else in allow mode.
+func Apply(config Config) string {
+	if err := config.Validate(); err != nil {
+		return "Error: " + err.Error()
+	}
+	countries, err := Load(config.Database)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	selected := map[string]bool{}
+	for _, code := range config.Countries {
+		selected[code] = true
+	}
+	var prefixes []netip.Prefix
+	for _, country := range countries {
+		if selected[country.Code] {
+			prefixes = append(prefixes, country.Prefixes...)
+		}
+	}
+	if len(prefixes) == 0 {
+		return "Error: the database has no networks for the selected countries"
+	}
+	if config.Mode == ModeAllow {
+		for _, network := range privateNetworks {
+			prefixes = append(prefixes, netip.MustParsePrefix(network))
+		}
+	}
+	prefixes = netext.AggregatePrefixes(prefixes)
+
+	if err := ipset.Apply(setName, prefixes); err != nil {
+		return "Error: " + err.Error()
+	}
+	// the port or mode may have changed, replace the match rules
+	if err := ipset.RemoveMatchRules(setName); err != nil {
+		return "Error: " + err.Error()
+	}
+	rule := ipset.MatchRule{Negate: config.Mode == ModeAllow, Protocol: config.Protocol, Port: config.Port, Target: "DROP"}
+	if err := ipset.AddMatchRules(setName, rule); err != nil {
+		return "Error: " + err.Error()
+	}
+	output := ufw.Reload()
+
+	config.Networks = len(prefixes)
+	config.UpdatedAt = time.Now()
+	if err := saveConfig(config); err != nil {
+		return output + "Error: saving geoip config: " + err.Error()
+	}
+	return output + fmt.Sprintf("GeoIP %s %s: %d networks applied\n", config.Mode, strings.Join(config.Countries, ","), len(prefixes))
+}
+
+// Refresh applies the saved config again with the current database.
+func Refresh() string {
+	config, err := LoadConfig()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if config == nil {
+		return "Error: no GeoIP restriction applied"
+	}
+	return Apply(*config)
+}
+
+// Remove deletes the restriction and its sets.
+func Remove() string {
+	if err := ipset.RemoveMatchRules(setName); err != nil {
+		return "Error: " + err.Error()
+	}
+	output := ufw.Reload()
+	if err := ipset.Destroy(setName); err != nil {
+		return output + "Error: " + err.Error()
+	}
+	if err := os.Remove(state.Path(storeFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return output + "Error: " + err.Error()
+	}
+	return output + "GeoIP restriction removed\n"
+}
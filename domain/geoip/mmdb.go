@@ -0,0 +1,332 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+
+	"github.com/samber/lo"
+)
+
+// A minimal reader for MaxMind DB (.mmdb) files, enough to list every network
+// of a country database. See https://maxmind.github.io/MaxMind-DB/ for the format.
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxDecodeDepth bounds nested maps, arrays and pointers; real databases nest
+// a few levels, deeper data is corrupt or a pointer loop.
+const maxDecodeDepth = 32
+
+type mmdbReader struct {
+	buffer     []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	dataStart  uint
+}
+
+func loadMMDB(path string) (map[string]*Country, error) {
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	markerAt := bytes.LastIndex(buffer, metadataMarker)
+	if markerAt == -1 {
+		return nil, fmt.Errorf("%s is not a MaxMind DB file", path)
+	}
+	metadata, _, err := decode(buffer[markerAt+len(metadataMarker):], 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata: %w", err)
+	}
+	meta, ok := metadata.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid metadata")
+	}
+
+	r := mmdbReader{
+		buffer:     buffer,
+		nodeCount:  toUint(meta["node_count"]),
+		recordSize: toUint(meta["record_size"]),
+		ipVersion:  toUint(meta["ip_version"]),
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version %d", r.ipVersion)
+	}
+	bits := lo.Ternary(r.ipVersion == 4, 32, 128)
+	r.dataStart = r.nodeCount*r.recordSize/4 + 16
+	if r.dataStart > uint(markerAt) {
+		return nil, fmt.Errorf("invalid search tree size")
+	}
+
+	countries := map[string]*Country{}
+	records := map[uint]*Country{} // many networks share a data record
+	var walkErr error
+	var address [16]byte
+
+	var walk func(node uint, depth int)
+	walk = func(node uint, depth int) {
+		if walkErr != nil {
+			return
+		}
+		if depth >= bits {
+			// every path ends in a record after at most bits nodes, deeper means a loop
+			walkErr = fmt.Errorf("search tree deeper than %d bits", bits)
+			return
+		}
+		if r.ipVersion == 6 && r.isAlias(address, depth) {
+			return // IPv4 networks are also reachable at ::ffff:0:0/96 and 2002::/16
+		}
+
+		for bit := uint(0); bit < 2; bit++ {
+			record := r.readRecord(node, bit)
+			if bit == 1 {
+				address[depth/8] |= 1 << (7 - depth%8)
+			}
+			switch {
+			case record < r.nodeCount:
+				walk(record, depth+1)
+			case record > r.nodeCount:
+				country, err := r.country(record, records, countries)
+				if err != nil {
+					walkErr = err
+					return
+				}
+				if country != nil {
+					country.Prefixes = append(country.Prefixes, r.prefix(address, depth+1))
+				}
+			}
+			if bit == 1 {
+				address[depth/8] &^= 1 << (7 - depth%8)
+			}
+		}
+	}
+	walk(0, 0)
+
+	return countries, walkErr
+}
+
+func (r mmdbReader) readRecord(node, bit uint) uint {
+	size := r.recordSize * 2 / 8
+	b := r.buffer[node*size : node*size+size]
+	switch r.recordSize {
+	case 24:
+		if bit == 0 {
+			return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3])<<16 | uint(b[4])<<8 | uint(b[5])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	}
+	if bit == 0 {
+		return uint(binary.BigEndian.Uint32(b[0:4]))
+	}
+	return uint(binary.BigEndian.Uint32(b[4:8]))
+}
+
+// isAlias reports whether the walk reached one of the IPv6 aliases of the IPv4 subtree.
+func (r mmdbReader) isAlias(address [16]byte, depth int) bool {
+	switch depth {
+	case 16:
+		return address[0] == 0x20 && address[1] == 0x02
+	case 96:
+		return bytes.Equal(address[:10], make([]byte, 10)) && address[10] == 0xff && address[11] == 0xff
+	}
+	return false
+}
+
+// prefix converts a tree position to a network; IPv4 networks of an IPv6 tree
+// live under ::/96.
+func (r mmdbReader) prefix(address [16]byte, bits int) netip.Prefix {
+	if r.ipVersion == 4 {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(address[:4])), bits)
+	}
+	if bits >= 96 && bytes.Equal(address[:12], make([]byte, 12)) {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(address[12:])), bits-96)
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(address), bits)
+}
+
+func (r mmdbReader) country(record uint, records map[uint]*Country, countries map[string]*Country) (*Country, error) {
+	offset := record - r.nodeCount - 16
+	if country, ok := records[offset]; ok {
+		return country, nil
+	}
+
+	value, _, err := decode(r.buffer[r.dataStart:], offset, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reading record: %w", err)
+	}
+	code, name := countryOf(value)
+	if code == "" {
+		records[offset] = nil
+		return nil, nil
+	}
+	country, ok := countries[code]
+	if !ok {
+		country = &Country{Code: code, Name: name}
+		countries[code] = country
+	}
+	records[offset] = country
+	return country, nil
+}
+
+// countryOf reads country.iso_code and country.names.en, falling back to the
+// registered country for networks without a location such as anycast ranges.
+func countryOf(value any) (string, string) {
+	record, _ := value.(map[string]any)
+	for _, key := range []string{"country", "registered_country"} {
+		country, _ := record[key].(map[string]any)
+		code, _ := country["iso_code"].(string)
+		if code == "" {
+			continue
+		}
+		names, _ := country["names"].(map[string]any)
+		name, _ := names["en"].(string)
+		return code, name
+	}
+	return "", ""
+}
+
+// decode reads the value at offset of the data section and returns it with
+// the offset following it. depth counts the maps, arrays and pointers the
+// value is nested in.
+func decode(data []byte, offset uint, depth int) (any, uint, error) {
+	if offset >= uint(len(data)) {
+		return nil, 0, fmt.Errorf("offset %d out of range", offset)
+	}
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("data nested deeper than %d levels", maxDecodeDepth)
+	}
+	control := data[offset]
+	offset++
+	kind := uint(control >> 5)
+
+	if kind == 1 { // pointer
+		size := uint(control>>3) & 0x3
+		value := uint(control & 0x7)
+		if offset+size+1 > uint(len(data)) {
+			return nil, 0, fmt.Errorf("pointer out of range")
+		}
+		var pointer uint
+		switch size {
+		case 0:
+			pointer = value<<8 | uint(data[offset])
+		case 1:
+			pointer = (value<<16 | uint(data[offset])<<8 | uint(data[offset+1])) + 2048
+		case 2:
+			pointer = (value<<24 | uint(data[offset])<<16 | uint(data[offset+1])<<8 | uint(data[offset+2])) + 526336
+		case 3:
+			pointer = uint(binary.BigEndian.Uint32(data[offset : offset+4]))
+		}
+		target, _, err := decode(data, pointer, depth+1)
+		return target, offset + size + 1, err
+	}
+
+	if kind == 0 { // extended type
+		if offset >= uint(len(data)) {
+			return nil, 0, fmt.Errorf("truncated data")
+		}
+		kind = 7 + uint(data[offset])
+		offset++
+	}
+
+	size := uint(control & 0x1f)
+	if size >= 29 {
+		extra := size - 28
+		if offset+extra > uint(len(data)) {
+			return nil, 0, fmt.Errorf("truncated data")
+		}
+		n := uint(0)
+		for _, b := range data[offset : offset+extra] {
+			n = n<<8 | uint(b)
+		}
+		size = []uint{29, 285, 65821}[extra-1] + n
+		offset += extra
+	}
+
+	if (kind == 7 || kind == 11) && size > uint(len(data))-offset {
+		// every entry takes at least one byte
+		return nil, 0, fmt.Errorf("truncated data")
+	}
+
+	switch kind {
+	case 7: // map
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := decode(data, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, after, err := decode(data, next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyString, _ := key.(string)
+			m[keyString] = value
+			offset = after
+		}
+		return m, offset, nil
+	case 11: // array
+		a := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := decode(data, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			offset = next
+		}
+		return a, offset, nil
+	case 14: // boolean, the value is the size
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(data)) {
+		return nil, 0, fmt.Errorf("truncated data")
+	}
+	raw := data[offset : offset+size]
+	offset += size
+
+	switch kind {
+	case 2: // string
+		return string(raw), offset, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, fmt.Errorf("double of %d bytes", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), offset, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, fmt.Errorf("float of %d bytes", size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(raw)), offset, nil
+	case 5, 6, 9, 10: // unsigned integers, 128 bit values are truncated
+		n := uint(0)
+		for _, b := range raw {
+			n = n<<8 | uint(b)
+		}
+		return n, offset, nil
+	case 8: // int32
+		n := int32(0)
+		for _, b := range raw {
+			n = n<<8 | int32(b)
+		}
+		return n, offset, nil
+	}
+	return raw, offset, nil // bytes and anything unknown
+}
+
+func toUint(value any) uint {
+	n, _ := value.(uint)
+	return n
+}
@@ -0,0 +1,266 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/countries.mmdb")
+
+const fixturePath = "testdata/countries.mmdb"
+
+// fixtureNetworks are the networks of testdata/countries.mmdb. The aliases of
+// the IPv4 subtree must not show up as networks of their own.
+var fixtureNetworks = []struct {
+	prefix  string
+	country string
+}{
+	{"::1.2.3.0/120", "DE"},
+	{"::5.6.0.0/112", "DE"},
+	{"2001:db8::/32", "FR"},
+	{"::ffff:1.2.3.0/120", "DE"},   // alias of 1.2.3.0/24
+	{"2002:102:300::/40", "DE"},    // 6to4 alias of 1.2.3.0/24
+	{"2001:db9::/32", "unlocated"}, // no country, skipped
+}
+
+func TestLoadMMDB(t *testing.T) {
+	if *update {
+		if err := os.WriteFile(fixturePath, buildMMDB(t, 24), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	countries, err := loadMMDB(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"DE": {"1.2.3.0/24", "5.6.0.0/16"},
+		"FR": {"2001:db8::/32"},
+	}
+	if len(countries) != len(want) {
+		t.Fatalf("got %d countries, want %d", len(countries), len(want))
+	}
+	for code, prefixes := range want {
+		country, ok := countries[code]
+		if !ok {
+			t.Fatalf("country %s missing", code)
+		}
+		var got []string
+		for _, prefix := range country.Prefixes {
+			got = append(got, prefix.String())
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, prefixes) {
+			t.Errorf("%s: got %v, want %v", code, got, prefixes)
+		}
+	}
+	if name := countries["DE"].Name; name != "Germany" {
+		t.Errorf("DE name: got %q", name)
+	}
+}
+
+func TestLoadMMDBRecordSizes(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		path := filepath.Join(t.TempDir(), "countries.mmdb")
+		if err := os.WriteFile(path, buildMMDB(t, recordSize), 0644); err != nil {
+			t.Fatal(err)
+		}
+		countries, err := loadMMDB(path)
+		if err != nil {
+			t.Fatalf("record size %d: %v", recordSize, err)
+		}
+		if len(countries["DE"].Prefixes) != 2 || len(countries["FR"].Prefixes) != 1 {
+			t.Errorf("record size %d: got %+v", recordSize, countries)
+		}
+	}
+}
+
+func TestLoadMMDBMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"no metadata", []byte("not a database"), "not a MaxMind DB"},
+		{"unsupported record size", withMetadata(nil, 1, 20, 6), "unsupported record size"},
+		{"unsupported ip version", withMetadata(nil, 1, 24, 5), "unsupported IP version"},
+		{"tree larger than file", withMetadata(nil, 1000, 24, 6), "invalid search tree size"},
+		// node 0 points to itself on both sides, a walk would never end
+		{"tree loop", withMetadata(append([]byte{0, 0, 0, 0, 0, 0}, make([]byte, 16)...), 1, 24, 4), "deeper than 32 bits"},
+		// a chain of 130 nodes each leading to the next one
+		{"tree too deep", withMetadata(deepTree(130), 130, 24, 6), "deeper than 128 bits"},
+		// the only record points to data behind the end of the data section
+		{"record out of range", withMetadata(append([]byte{0x7f, 0xff, 0xff, 0, 0, 1}, make([]byte, 16)...), 1, 24, 4), "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "broken.mmdb")
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadMMDB(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    any
+		wantErr string
+	}{
+		{"string", []byte{2<<5 | 2, 'd', 'e'}, "de", ""},
+		{"uint16", []byte{5<<5 | 2, 0x01, 0x02}, uint(258), ""},
+		{"double", append([]byte{3<<5 | 8}, float64Bytes(1.5)...), 1.5, ""},
+		{"float", []byte{0<<5 | 4, 8, 0x3f, 0xc0, 0, 0}, float32(1.5), ""},
+		{"boolean", []byte{0<<5 | 1, 7}, true, ""},
+		{"pointer", []byte{1 << 5, 2, 2<<5 | 1, 'x'}, "x", ""},
+		{"double of 4 bytes", []byte{3<<5 | 4, 0, 0, 0, 0}, nil, "double of 4 bytes"},
+		{"float of 8 bytes", append([]byte{0<<5 | 8, 8}, make([]byte, 8)...), nil, "float of 8 bytes"},
+		{"truncated string", []byte{2<<5 | 5, 'a'}, nil, "truncated"},
+		{"pointer to itself", []byte{1 << 5, 0}, nil, "nested deeper"},
+		{"map larger than data", []byte{7<<5 | 28, 2<<5 | 1, 'k'}, nil, "truncated"},
+		{"pointer out of range", []byte{1<<5 | 3<<3}, nil, "pointer out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := decode(tt.data, 0, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildMMDB writes an IPv6 database holding fixtureNetworks.
+func buildMMDB(t *testing.T, recordSize int) []byte {
+	t.Helper()
+
+	var data []byte
+	offsets := map[string]int{}
+	for _, record := range []struct{ code, name string }{{"DE", "Germany"}, {"FR", "France"}} {
+		offsets[record.code] = len(data)
+		data = append(data, encodeMap(
+			"country", encodeMap(
+				"iso_code", encodeString(record.code),
+				"names", encodeMap("en", encodeString(record.name)),
+			),
+		)...)
+	}
+	offsets["unlocated"] = len(data)
+	data = append(data, encodeMap("continent", encodeMap("code", encodeString("EU")))...)
+
+	// nodes hold two records: a node index, or -1 - data offset, or -1 - len(data) for empty
+	empty := -1 - len(data) - 1
+	nodes := [][2]int{{empty, empty}}
+	for _, network := range fixtureNetworks {
+		prefix := netip.MustParsePrefix(network.prefix)
+		address := prefix.Addr().As16()
+		node := 0
+		for depth := 0; depth < prefix.Bits(); depth++ {
+			bit := address[depth/8] >> (7 - depth%8) & 1
+			if depth == prefix.Bits()-1 {
+				nodes[node][bit] = -1 - offsets[network.country]
+				break
+			}
+			if nodes[node][bit] <= 0 {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+	value := func(record int) uint32 {
+		switch {
+		case record > 0:
+			return uint32(record)
+		case record == empty:
+			return uint32(nodeCount)
+		}
+		return uint32(nodeCount + 16 + (-1 - record))
+	}
+	var tree []byte
+	for _, node := range nodes {
+		left, right := value(node[0]), value(node[1])
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left), byte(left>>24&0x0f)<<4|byte(right>>24&0x0f), byte(right>>16), byte(right>>8), byte(right))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(tree, left)
+			tree = binary.BigEndian.AppendUint32(tree, right)
+		}
+	}
+
+	content := append(tree, make([]byte, 16)...)
+	content = append(content, data...)
+	return withMetadata(content, nodeCount, recordSize, 6)
+}
+
+// deepTree chains count nodes, each with the next node as its left record.
+func deepTree(count int) []byte {
+	var tree []byte
+	for node := 1; node <= count; node++ {
+		next := uint32(min(node, count))
+		tree = append(tree, byte(next>>16), byte(next>>8), byte(next), byte(count>>16), byte(count>>8), byte(count))
+	}
+	return append(tree, make([]byte, 16)...)
+}
+
+func withMetadata(content []byte, nodeCount, recordSize, ipVersion int) []byte {
+	content = append(slices.Clone(content), metadataMarker...)
+	return append(content, encodeMap(
+		"node_count", encodeUint32(uint32(nodeCount)),
+		"record_size", encodeUint32(uint32(recordSize)),
+		"ip_version", encodeUint32(uint32(ipVersion)),
+	)...)
+}
+
+func encodeString(s string) []byte {
+	return append([]byte{2<<5 | byte(len(s))}, s...)
+}
+
+func encodeUint32(n uint32) []byte {
+	return binary.BigEndian.AppendUint32([]byte{6<<5 | 4}, n)
+}
+
+// encodeMap takes alternating keys and encoded values.
+func encodeMap(pairs ...any) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteByte(7<<5 | byte(len(pairs)/2))
+	for i := 0; i < len(pairs); i += 2 {
+		buffer.Write(encodeString(pairs[i].(string)))
+		buffer.Write(pairs[i+1].([]byte))
+	}
+	return buffer.Bytes()
+}
+
+func float64Bytes(f float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(f))
+}
@@ -0,0 +1,243 @@
+package ipset
+
+import (
+	"errors"
+	"fmt"
+	"fwtui/domain/rulesfile"
+	"fwtui/domain/state"
+	"io/fs"
+	"net/netip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// saveDir keeps every set fwtui created in `ipset restore` format, so the
+// before.init hook can recreate them before ufw loads before.rules at boot.
+var saveDir = state.Path("ipsets")
+
+const (
+	BeforeInitPath = "/etc/ufw/before.init"
+	hookBegin      = "# BEGIN fwtui ipsets"
+	hookEnd        = "# END fwtui ipsets"
+)
+
+// Apply creates or atomically replaces the set with the given prefixes. Sets
+// hold one address family, so IPv6 prefixes go to "<name>-v6".
+func Apply(name string, prefixes []netip.Prefix) error {
+	v4, v6 := split(prefixes)
+	for _, set := range []struct {
+		name     string
+		family   string
+		prefixes []netip.Prefix
+	}{{name, "inet", v4}, {V6Name(name), "inet6", v6}} {
+		if err := apply(set.name, set.family, set.prefixes); err != nil {
+			return err
+		}
+	}
+	return ensureBootHook()
+}
+
+func V6Name(name string) string {
+	return name + "-v6"
+}
+
+func apply(name, family string, prefixes []netip.Prefix) error {
+	maxElem := max(65536, len(prefixes))
+	create := fmt.Sprintf("create %%s hash:net family %s maxelem %d -exist", family, maxElem)
+	tmp := name + "-tmp"
+
+	var script strings.Builder
+	fmt.Fprintf(&script, create+"\n", name)
+	fmt.Fprintf(&script, create+"\n", tmp)
+	fmt.Fprintf(&script, "flush %s\n", tmp)
+	for _, prefix := range prefixes {
+		fmt.Fprintf(&script, "add %s %s\n", tmp, prefix)
+	}
+	fmt.Fprintf(&script, "swap %s %s\n", tmp, name)
+	fmt.Fprintf(&script, "destroy %s\n", tmp)
+	if err := restore(script.String()); err != nil {
+		return err
+	}
+
+	var saved strings.Builder
+	fmt.Fprintf(&saved, create+"\n", name)
+	fmt.Fprintf(&saved, "flush %s\n", name)
+	for _, prefix := range prefixes {
+		fmt.Fprintf(&saved, "add %s %s\n", name, prefix)
+	}
+	if err := os.MkdirAll(saveDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(saveDir, name+".ipset"), []byte(saved.String()), 0644)
+}
+
+// Destroy removes both sets of name. The before.rules entries using them have
+// to be removed and ufw reloaded first, ipset refuses to destroy a set in use.
+func Destroy(name string) error {
+	for _, set := range []string{name, V6Name(name)} {
+		out, err := exec.Command("sudo", "ipset", "destroy", set).CombinedOutput()
+		if err != nil && !strings.Contains(string(out), "does not exist") {
+			return fmt.Errorf("ipset destroy %s: %s", set, strings.TrimSpace(string(out)))
+		}
+		if err := os.Remove(filepath.Join(saveDir, set+".ipset")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func restore(script string) error {
+	cmd := exec.Command("sudo", "ipset", "restore")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ipset restore: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func split(prefixes []netip.Prefix) (v4, v6 []netip.Prefix) {
+	for _, prefix := range prefixes {
+		if prefix.Addr().Is4() {
+			v4 = append(v4, prefix)
+		} else {
+			v6 = append(v6, prefix)
+		}
+	}
+	return v4, v6
+}
+
+// MatchRule describes the before.rules entry using a set.
+type MatchRule struct {
+	Negate   bool   // match sources outside the set, for new connections only
+	Protocol string // with Port, only match one service
+	Port     string
+	Target   string // DROP, ACCEPT, ...
+}
+
+func (r MatchRule) args(set string) string {
+	var parts []string
+	if r.Port != "" {
+		parts = append(parts, "-p", r.Protocol, "--dport", r.Port)
+	}
+	if r.Negate {
+		// replies to connections this host opened must still come in
+		parts = append(parts, "-m conntrack --ctstate NEW -m set ! --match-set", set)
+	} else {
+		parts = append(parts, "-m set --match-set", set)
+	}
+	return strings.Join(append(parts, "src -j", r.Target), " ")
+}
+
+// AddMatchRules adds the match rule to before.rules and before6.rules, right
+// after ufw's loopback and established-connection accepts, so local traffic
+// and replies keep flowing, but ahead of every other input rule.
+func AddMatchRules(name string, rule MatchRule) error {
+	// rules using a set that is not restored on boot keep ufw from starting
+	if err := ensureBootHook(); err != nil {
+		return err
+	}
+	for _, file := range []struct{ path, chain, set string }{
+		{"/etc/ufw/before.rules", "ufw-before-input", name},
+		{"/etc/ufw/before6.rules", "ufw6-before-input", V6Name(name)},
+	} {
+		rules, err := rulesfile.Load(file.path)
+		if err != nil {
+			return err
+		}
+		if hasMatchRule(rules, file.set) {
+			continue
+		}
+		if err := rules.InsertRuleAfter("filter", file.chain, rule.args(file.set), isLeadingAccept); err != nil {
+			return err
+		}
+		if err := rules.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveMatchRules removes the before.rules entries using the set.
+func RemoveMatchRules(name string) error {
+	for _, file := range []struct{ path, set string }{
+		{"/etc/ufw/before.rules", name},
+		{"/etc/ufw/before6.rules", V6Name(name)},
+	} {
+		rules, err := rulesfile.Load(file.path)
+		if err != nil {
+			return err
+		}
+		if !hasMatchRule(rules, file.set) {
+			continue
+		}
+		rules.SetLines(matchRuleFree(rules.Lines(), file.set))
+		if err := rules.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isLeadingAccept matches the accepts ufw puts first in its before rules.
+func isLeadingAccept(text string) bool {
+	return strings.HasSuffix(text, "-i lo -j ACCEPT") || strings.HasSuffix(text, "--ctstate RELATED,ESTABLISHED -j ACCEPT")
+}
+
+func hasMatchRule(rules *rulesfile.File, set string) bool {
+	return len(matchRuleFree(rules.Lines(), set)) != len(rules.Lines())
+}
+
+func matchRuleFree(lines []string, set string) []string {
+	needle := "--match-set " + set + " "
+	var kept []string
+	for _, line := range lines {
+		if !strings.Contains(line, needle) {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// ensureBootHook makes ufw's before.init restore the saved sets on start.
+func ensureBootHook() error {
+	data, err := os.ReadFile(BeforeInitPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		data = []byte("#!/bin/sh\nset -e\n\ncase \"$1\" in\nstart)\n    ;;\nstop)\n    ;;\nstatus)\n    ;;\nflush-all)\n    ;;\n*)\n    echo \"'$1' not supported\"\n    echo \"Usage: before.init {start|stop|flush-all|status}\"\n    ;;\nesac\n")
+	} else if err != nil {
+		return fmt.Errorf("reading %s: %w", BeforeInitPath, err)
+	}
+
+	content := string(data)
+	if strings.Contains(content, hookBegin) {
+		return makeExecutable()
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "start)" {
+			hook := []string{
+				"    " + hookBegin,
+				fmt.Sprintf("    for f in %s/*.ipset; do [ -e \"$f\" ] && ipset restore -exist < \"$f\"; done", saveDir),
+				"    " + hookEnd,
+			}
+			lines = append(lines[:i+1:i+1], append(hook, lines[i+1:]...)...)
+			if err := os.WriteFile(BeforeInitPath, []byte(strings.Join(lines, "\n")), 0750); err != nil {
+				return err
+			}
+			return makeExecutable()
+		}
+	}
+	return fmt.Errorf("no start) case found in %s", BeforeInitPath)
+}
+
+// makeExecutable sets the mode of before.init, which WriteFile leaves alone
+// for existing files. ufw only runs the hook when it is executable; without it
+// the match rules refer to sets missing after a reboot and ufw fails to start.
+func makeExecutable() error {
+	if err := os.Chmod(BeforeInitPath, 0750); err != nil {
+		return fmt.Errorf("making %s executable: %w", BeforeInitPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package lint
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/utils/netext"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type Kind string
+
+const (
+	KindShadowed     Kind = "shadowed"
+	KindDuplicate    Kind = "duplicate"
+	KindInconsistent Kind = "v4/v6"
+	KindOverlap      Kind = "overlap"
+	KindExposed      Kind = "exposed"
+)
+
+type Finding struct {
+	Kind    Kind
+	Rule    entity.Rule
+	Message string
+}
+
+// SensitivePorts are services that should rarely be reachable from anywhere.
+var SensitivePorts = map[int]string{
+	22:    "SSH",
+	23:    "Telnet",
+	139:   "NetBIOS",
+	445:   "SMB",
+	1433:  "MSSQL",
+	2375:  "Docker API",
+	2376:  "Docker API (TLS)",
+	3306:  "MySQL",
+	3389:  "RDP",
+	5432:  "PostgreSQL",
+	5900:  "VNC",
+	5984:  "CouchDB",
+	6379:  "Redis",
+	9200:  "Elasticsearch",
+	11211: "Memcached",
+	27017: "MongoDB",
+}
+
+// Lint checks the rule set in ufw evaluation order. apps resolves application
+// profile names to their port specs.
+func Lint(rules []entity.Rule, apps map[string][]string) []Finding {
+	var findings []Finding
+	findings = append(findings, findShadowed(rules, apps)...)
+	findings = append(findings, findInconsistentVersions(rules)...)
+	findings = append(findings, findExposed(rules, apps)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Rule.Number < findings[j].Rule.Number
+	})
+	return findings
+}
+
+// findShadowed reports rules that an earlier rule fully covers (they never
+// fire), exact duplicates, and port ranges partly overlapping with a
+// different action.
+func findShadowed(rules []entity.Rule, apps map[string][]string) []Finding {
+	var findings []Finding
+	for j, later := range rules {
+		for _, earlier := range rules[:j] {
+			if earlier.V6 != later.V6 || earlier.Direction != later.Direction {
+				continue
+			}
+
+			switch {
+			case specWithoutComment(earlier) == specWithoutComment(later):
+				findings = append(findings, Finding{
+					Kind:    KindDuplicate,
+					Rule:    later,
+					Message: fmt.Sprintf("duplicates rule [%d]", earlier.Number),
+				})
+			case covers(earlier, later, apps):
+				message := fmt.Sprintf("never matches, rule [%d] (%s) matches all of its traffic first", earlier.Number, earlier.Action)
+				if earlier.IsAllowing() == later.IsAllowing() {
+					message = fmt.Sprintf("redundant, rule [%d] (%s) already matches all of its traffic", earlier.Number, earlier.Action)
+				}
+				findings = append(findings, Finding{Kind: KindShadowed, Rule: later, Message: message})
+			case earlier.IsAllowing() != later.IsAllowing() && partlyOverlaps(earlier, later, apps):
+				findings = append(findings, Finding{
+					Kind:    KindOverlap,
+					Rule:    later,
+					Message: fmt.Sprintf("partly overlaps rule [%d] with a different action (%s vs %s)", earlier.Number, earlier.Action, later.Action),
+				})
+			default:
+				continue
+			}
+			break // report each rule against the first earlier rule only
+		}
+	}
+	return findings
+}
+
+// findInconsistentVersions compares rules without addresses, which ufw
+// normally creates for both IPv4 and IPv6.
+func findInconsistentVersions(rules []entity.Rule) []Finding {
+	hasV6 := lo.SomeBy(rules, func(r entity.Rule) bool { return r.V6 })
+	if !hasV6 {
+		return nil // IPv6 disabled, nothing to compare
+	}
+
+	generic := lo.Filter(rules, func(r entity.Rule, _ int) bool {
+		return r.From.Address == "" && r.To.Address == ""
+	})
+
+	var findings []Finding
+	for _, rule := range generic {
+		counterparts := lo.Filter(generic, func(other entity.Rule, _ int) bool {
+			return other.V6 != rule.V6 && versionKey(other) == versionKey(rule)
+		})
+
+		if len(counterparts) == 0 {
+			findings = append(findings, Finding{
+				Kind:    KindInconsistent,
+				Rule:    rule,
+				Message: fmt.Sprintf("exists for %s only", rule.IPVersion()),
+			})
+			continue
+		}
+
+		if !rule.V6 {
+			for _, other := range counterparts {
+				if other.Action != rule.Action {
+					findings = append(findings, Finding{
+						Kind:    KindInconsistent,
+						Rule:    rule,
+						Message: fmt.Sprintf("is %s for v4 but rule [%d] is %s for v6", rule.Action, other.Number, other.Action),
+					})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func findExposed(rules []entity.Rule, apps map[string][]string) []Finding {
+	var findings []Finding
+	for _, rule := range rules {
+		if rule.Action != "allow" || rule.Direction != "in" || rule.From.Address != "" || isLoopback(rule.To) {
+			continue
+		}
+
+		var services []string
+		for _, port := range sortedSensitivePorts() {
+			if !rule.To.AnyPort() && rule.To.MatchesPort(port, "tcp", apps) {
+				services = append(services, fmt.Sprintf("%s (%d)", SensitivePorts[port], port))
+			}
+		}
+		if rule.To.AnyPort() && rule.To.Protocol != "udp" {
+			services = []string{"every port"}
+		}
+
+		if len(services) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("allows %s from anywhere, consider restricting the source or using limit", strings.Join(services, ", "))
+		if rule.To.Interface != "" {
+			// fine for an internal interface, only the user knows which one it is
+			message = fmt.Sprintf("allows %s from anywhere on %s, restrict the source if %s faces the internet",
+				strings.Join(services, ", "), rule.To.Interface, rule.To.Interface)
+		}
+		findings = append(findings, Finding{Kind: KindExposed, Rule: rule, Message: message})
+	}
+	return findings
+}
+
+// isLoopback reports whether the endpoint only accepts local traffic.
+func isLoopback(endpoint entity.Endpoint) bool {
+	if endpoint.Interface == "lo" {
+		return true
+	}
+	if endpoint.Address == "" {
+		return false
+	}
+	network, err := netext.ParseIPOrCIDR(endpoint.Address)
+	return err == nil && network.IP.IsLoopback()
+}
+
+func sortedSensitivePorts() []int {
+	ports := lo.Keys(SensitivePorts)
+	sort.Ints(ports)
+	return ports
+}
+
+func specWithoutComment(rule entity.Rule) string {
+	rule.Comment = ""
+	return rule.Spec()
+}
+
+// versionKey identifies a rule regardless of action, comment and IP version.
+func versionKey(rule entity.Rule) string {
+	rule.Comment = ""
+	rule.Action = ""
+	return rule.Spec()
+}
+
+// covers reports whether every packet matched by inner is matched by outer.
+func covers(outer, inner entity.Rule, apps map[string][]string) bool {
+	return interfaceCovers(outer.To.Interface, inner.To.Interface) &&
+		interfaceCovers(outer.From.Interface, inner.From.Interface) &&
+		addressCovers(outer.From.Address, inner.From.Address) &&
+		addressCovers(outer.To.Address, inner.To.Address) &&
+		portsCover(outer.From, inner.From, apps) &&
+		portsCover(outer.To, inner.To, apps)
+}
+
+// overlaps reports whether some packet could be matched by both rules.
+func overlaps(a, b entity.Rule, apps map[string][]string) bool {
+	return interfacesOverlap(a.To.Interface, b.To.Interface) &&
+		interfacesOverlap(a.From.Interface, b.From.Interface) &&
+		addressesOverlap(a.From.Address, b.From.Address) &&
+		addressesOverlap(a.To.Address, b.To.Address) &&
+		portsOverlap(a.From, b.From, apps) &&
+		portsOverlap(a.To, b.To, apps)
+}
+
+// partlyOverlaps reports rules whose port ranges intersect without either
+// covering the other, e.g. 8000:8100 and 8050:8200. A rule without ports,
+// such as "deny from <network>", overlaps every port rule and is left out.
+func partlyOverlaps(a, b entity.Rule, apps map[string][]string) bool {
+	if a.To.AnyPort() || b.To.AnyPort() {
+		return false
+	}
+	return overlaps(a, b, apps) && !portsCover(a.To, b.To, apps) && !portsCover(b.To, a.To, apps)
+}
+
+func interfaceCovers(outer, inner string) bool {
+	return outer == "" || outer == inner
+}
+
+func interfacesOverlap(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func addressCovers(outer, inner string) bool {
+	if outer == "" {
+		return true
+	}
+	if inner == "" {
+		return false
+	}
+	outerNet, err1 := netext.ParseIPOrCIDR(outer)
+	innerNet, err2 := netext.ParseIPOrCIDR(inner)
+	return err1 == nil && err2 == nil && netext.Contains(outerNet, innerNet)
+}
+
+func addressesOverlap(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	aNet, err1 := netext.ParseIPOrCIDR(a)
+	bNet, err2 := netext.ParseIPOrCIDR(b)
+	return err1 == nil && err2 == nil && netext.Overlaps(aNet, bNet)
+}
+
+// portRanges returns the endpoint's ports, treating "any port" as the full range.
+func portRanges(e entity.Endpoint, apps map[string][]string) []entity.PortRange {
+	if e.AnyPort() {
+		return []entity.PortRange{{From: 0, To: 65535, Protocol: e.Protocol}}
+	}
+	return e.PortRanges(apps)
+}
+
+func portsCover(outer, inner entity.Endpoint, apps map[string][]string) bool {
+	outerRanges := portRanges(outer, apps)
+	innerRanges := portRanges(inner, apps)
+	if len(innerRanges) == 0 {
+		return false // unknown application profile
+	}
+	// ranges inside one rule do not overlap, so each inner range has to fit in one outer range
+	return lo.EveryBy(innerRanges, func(in entity.PortRange) bool {
+		return lo.SomeBy(outerRanges, func(out entity.PortRange) bool {
+			return out.Covers(in)
+		})
+	})
+}
+
+func portsOverlap(a, b entity.Endpoint, apps map[string][]string) bool {
+	bRanges := portRanges(b, apps)
+	return lo.SomeBy(portRanges(a, apps), func(ar entity.PortRange) bool {
+		return lo.SomeBy(bRanges, func(br entity.PortRange) bool {
+			return ar.Overlaps(br)
+		})
+	})
+}
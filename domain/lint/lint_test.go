@@ -0,0 +1,40 @@
+package lint
+
+import (
+	"fwtui/domain/entity"
+	"testing"
+)
+
+func TestFindExposed(t *testing.T) {
+	tests := []struct {
+		name        string
+		to          entity.Endpoint
+		from        entity.Endpoint
+		wantMessage string
+	}{
+		{"ssh from anywhere", entity.Endpoint{Ports: "22", Protocol: "tcp"}, entity.Endpoint{},
+			"allows SSH (22) from anywhere, consider restricting the source or using limit"},
+		{"every port", entity.Endpoint{}, entity.Endpoint{},
+			"allows every port from anywhere, consider restricting the source or using limit"},
+		{"restricted source", entity.Endpoint{Ports: "22", Protocol: "tcp"}, entity.Endpoint{Address: "10.0.0.0/8"}, ""},
+		{"loopback interface", entity.Endpoint{Ports: "5432", Interface: "lo"}, entity.Endpoint{}, ""},
+		{"loopback address", entity.Endpoint{Address: "127.0.0.1", Ports: "6379"}, entity.Endpoint{}, ""},
+		{"loopback ipv6 address", entity.Endpoint{Address: "::1", Ports: "6379"}, entity.Endpoint{}, ""},
+		{"other interface", entity.Endpoint{Ports: "3306", Interface: "eth1"}, entity.Endpoint{},
+			"allows MySQL (3306) from anywhere on eth1, restrict the source if eth1 faces the internet"},
+		{"insensitive port", entity.Endpoint{Ports: "443", Protocol: "tcp"}, entity.Endpoint{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := entity.Rule{Number: 1, Action: "allow", Direction: "in", To: tt.to, From: tt.from}
+			findings := findExposed([]entity.Rule{rule}, nil)
+			var got string
+			if len(findings) > 0 {
+				got = findings[0].Message
+			}
+			if got != tt.wantMessage {
+				t.Errorf("got %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
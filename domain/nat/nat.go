@@ -0,0 +1,294 @@
+package nat
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/rulesfile"
+	"fwtui/utils/netext"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const BeforeRulesPath = "/etc/ufw/before.rules"
+
+type Kind string
+
+const (
+	KindForward    Kind = "forward"    // PREROUTING DNAT
+	KindMasquerade Kind = "masquerade" // POSTROUTING MASQUERADE
+)
+
+// Entry is one DNAT or MASQUERADE rule of the *nat table in before.rules.
+type Entry struct {
+	Kind            Kind
+	Interface       string // incoming interface of a forward, outgoing interface of a masquerade
+	Protocol        string // tcp or udp, forwards only
+	Port            string // external port or range "8000:8010", forwards only
+	Destination     string // internal address, forwards only
+	DestinationPort string // internal port, empty keeps the external one
+	Source          string // masqueraded network, masquerades only
+}
+
+var (
+	forwardRegex    = regexp.MustCompile(`^-A PREROUTING -i (\S+) -p (tcp|udp) --dport (\S+) -j DNAT --to-destination ([^\s:]+)(?::(\S+))?$`)
+	masqueradeRegex = regexp.MustCompile(`^-A POSTROUTING -s (\S+) -o (\S+) -j MASQUERADE$`)
+)
+
+func (e Entry) Line() string {
+	if e.Kind == KindMasquerade {
+		return fmt.Sprintf("-A POSTROUTING -s %s -o %s -j MASQUERADE", e.Source, e.Interface)
+	}
+	destination := e.Destination
+	if e.DestinationPort != "" {
+		destination += ":" + strings.ReplaceAll(e.DestinationPort, ":", "-")
+	}
+	return fmt.Sprintf("-A PREROUTING -i %s -p %s --dport %s -j DNAT --to-destination %s", e.Interface, e.Protocol, e.Port, destination)
+}
+
+func (e Entry) String() string {
+	if e.Kind == KindMasquerade {
+		return fmt.Sprintf("masquerade %s out %s", e.Source, e.Interface)
+	}
+	destination := e.Destination
+	if e.DestinationPort != "" {
+		destination += ":" + e.DestinationPort
+	}
+	return fmt.Sprintf("forward %s %s/%s -> %s", e.Interface, e.Port, e.Protocol, destination)
+}
+
+// RouteTag marks the ufw route rule that lets the traffic of a forward through.
+func (e Entry) RouteTag() entity.Tag {
+	return entity.Tag{Key: "nat", Value: fmt.Sprintf("%s/%s/%s", e.Interface, e.Port, e.Protocol)}
+}
+
+// RouteAllowSpec lets the forwarded connections through ufw's FORWARD chain.
+func (e Entry) RouteAllowSpec() string {
+	port := e.DestinationPort
+	if port == "" {
+		port = e.Port
+	}
+	return fmt.Sprintf("route allow in on %s to %s port %s proto %s comment '%s port forward %s'",
+		e.Interface, e.Destination, port, e.Protocol, e.RouteTag(), e.Port)
+}
+
+// RouteRules returns the route rules added with the forward, by their tag or,
+// for rules added before the tag, by their "port forward <port>" comment.
+func (e Entry) RouteRules(rules []entity.Rule) []entity.Rule {
+	if e.Kind != KindForward {
+		return nil
+	}
+	var result []entity.Rule
+	for _, rule := range rules {
+		untagged := rule.Direction == "fwd" && rule.Comment == "port forward "+e.Port && rule.To.Address == e.Destination
+		if rule.HasTag(e.RouteTag()) || untagged {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+func parseEntry(line string) (Entry, bool) {
+	if match := forwardRegex.FindStringSubmatch(line); match != nil {
+		return Entry{
+			Kind:            KindForward,
+			Interface:       match[1],
+			Protocol:        match[2],
+			Port:            match[3],
+			Destination:     match[4],
+			DestinationPort: strings.ReplaceAll(match[5], "-", ":"),
+		}, true
+	}
+	if match := masqueradeRegex.FindStringSubmatch(line); match != nil {
+		return Entry{Kind: KindMasquerade, Source: match[1], Interface: match[2]}, true
+	}
+	return Entry{}, false
+}
+
+func (e Entry) Validate() error {
+	if e.Interface == "" {
+		return fmt.Errorf("interface is required")
+	}
+	if e.Kind == KindMasquerade {
+		// the rule goes to the *nat table of before.rules, which is IPv4 only
+		if network, err := netext.ParseIPOrCIDR(e.Source); err != nil || network.IP.To4() == nil {
+			return fmt.Errorf("invalid source IPv4 network: %s", e.Source)
+		}
+		return nil
+	}
+
+	if e.Protocol != "tcp" && e.Protocol != "udp" {
+		return fmt.Errorf("protocol must be tcp or udp")
+	}
+	if err := validatePorts(e.Port); err != nil {
+		return err
+	}
+	if ip := net.ParseIP(e.Destination); ip == nil || ip.To4() == nil {
+		return fmt.Errorf("invalid destination IPv4 address: %s", e.Destination)
+	}
+	if e.DestinationPort != "" {
+		return validatePorts(e.DestinationPort)
+	}
+	return nil
+}
+
+func validatePorts(ports string) error {
+	from, to, isRange := strings.Cut(ports, ":")
+	first, err := strconv.Atoi(from)
+	if err != nil || first < 1 || first > 65535 {
+		return fmt.Errorf("invalid port: %s", ports)
+	}
+	if !isRange {
+		return nil
+	}
+	last, err := strconv.Atoi(to)
+	if err != nil || last < first || last > 65535 {
+		return fmt.Errorf("invalid port range: %s", ports)
+	}
+	return nil
+}
+
+// Load returns the DNAT and MASQUERADE entries of the *nat sections of before.rules.
+func Load() ([]Entry, error) {
+	lines, err := readLines()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	inNat := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "*nat":
+			inNat = true
+		case line == "COMMIT":
+			inNat = false
+		case inNat:
+			if entry, ok := parseEntry(line); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Add appends the entry to the *nat section, creating the section in front
+// of the *filter table when before.rules has none.
+func Add(entry Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	lines, err := readLines()
+	if err != nil {
+		return err
+	}
+
+	chain := chainOf(entry)
+	start, end := natSection(lines)
+	if start == -1 {
+		block := []string{
+			"# NAT rules managed by fwtui",
+			"*nat",
+			":PREROUTING ACCEPT [0:0]",
+			":POSTROUTING ACCEPT [0:0]",
+			entry.Line(),
+			"COMMIT",
+			"",
+		}
+		return writeLines(insert(lines, filterStart(lines), block...))
+	}
+
+	if !hasChainDeclaration(lines[start:end], chain) {
+		lines = insert(lines, start+1, fmt.Sprintf(":%s ACCEPT [0:0]", chain))
+		end++
+	}
+	return writeLines(insert(lines, end, entry.Line()))
+}
+
+// Remove deletes the entry from the *nat sections.
+func Remove(entry Entry) error {
+	lines, err := readLines()
+	if err != nil {
+		return err
+	}
+
+	inNat := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "*nat":
+			inNat = true
+		case trimmed == "COMMIT":
+			inNat = false
+		case inNat:
+			if parsed, ok := parseEntry(trimmed); ok && parsed == entry {
+				return writeLines(append(lines[:i:i], lines[i+1:]...))
+			}
+		}
+	}
+	return fmt.Errorf("entry not found in %s: %s", BeforeRulesPath, entry)
+}
+
+func chainOf(entry Entry) string {
+	if entry.Kind == KindMasquerade {
+		return "POSTROUTING"
+	}
+	return "PREROUTING"
+}
+
+// natSection returns the line index of the first "*nat" and of its COMMIT, or -1.
+func natSection(lines []string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start == -1 && trimmed == "*nat" {
+			start = i
+		} else if start != -1 && trimmed == "COMMIT" {
+			return start, i
+		}
+	}
+	return -1, -1
+}
+
+func hasChainDeclaration(section []string, chain string) bool {
+	for _, line := range section {
+		if strings.HasPrefix(strings.TrimSpace(line), ":"+chain+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// filterStart returns the index of the "*filter" line, or 0.
+func filterStart(lines []string) int {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "*filter" {
+			return i
+		}
+	}
+	return 0
+}
+
+func insert(lines []string, index int, inserted ...string) []string {
+	result := make([]string, 0, len(lines)+len(inserted))
+	result = append(result, lines[:index]...)
+	result = append(result, inserted...)
+	return append(result, lines[index:]...)
+}
+
+func readLines() ([]string, error) {
+	file, err := rulesfile.Load(BeforeRulesPath)
+	if err != nil {
+		return nil, err
+	}
+	return file.Lines(), nil
+}
+
+// writeLines validates and backs up before.rules before replacing it.
+func writeLines(lines []string) error {
+	file := &rulesfile.File{Path: BeforeRulesPath}
+	file.SetLines(lines)
+	return file.Save()
+}
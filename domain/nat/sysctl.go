@@ -0,0 +1,76 @@
+package nat
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const SysctlPath = "/etc/ufw/sysctl.conf"
+
+const ipForwardKey = "net/ipv4/ip_forward"
+
+// IPForwardEnabled reports whether ufw's sysctl.conf enables IPv4 forwarding.
+func IPForwardEnabled() (bool, error) {
+	data, err := os.ReadFile(SysctlPath)
+	if err != nil {
+		return false, fmt.Errorf("reading %s: %w", SysctlPath, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := parseSysctl(line); ok && key == ipForwardKey {
+			return value == "1", nil
+		}
+	}
+	return false, nil
+}
+
+// SetIPForward enables or disables IPv4 forwarding in ufw's sysctl.conf. The
+// setting is commented out rather than removed when disabling, the way ufw
+// ships it.
+func SetIPForward(enabled bool) error {
+	data, err := os.ReadFile(SysctlPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", SysctlPath, err)
+	}
+
+	setting := ipForwardKey + "=1"
+	if !enabled {
+		setting = "#" + setting
+	}
+
+	lines := strings.Split(string(data), "\n")
+	found := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if key, _, ok := parseSysctl(trimmed); ok && key == ipForwardKey {
+			if !found {
+				lines[i] = setting
+			} else {
+				lines[i] = "#" + trimmed
+			}
+			found = true
+		}
+	}
+	if !found {
+		lines = append(lines, setting)
+	}
+
+	info, err := os.Stat(SysctlPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(SysctlPath, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+// parseSysctl splits an active "key=value" line; keys use "/" or "." separators.
+func parseSysctl(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.ReplaceAll(strings.TrimSpace(key), ".", "/"), strings.TrimSpace(value), true
+}
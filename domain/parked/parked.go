@@ -0,0 +1,212 @@
+package parked
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/state"
+	"fwtui/domain/ufw"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// storeFile keeps disabled rules so they can be enabled again later. ufw has
+// no notion of an inactive rule, so disabling deletes the rule from ufw.
+const storeFile = "parked-rules.json"
+
+type ParkedRule struct {
+	Spec     string    `json:"spec"`     // ufw rule syntax, see entity.Rule.Spec
+	Position int       `json:"position"` // rule number before it was disabled
+	Comment  string    `json:"comment"`
+	Line     string    `json:"line"` // `ufw status numbered` line for display
+	ParkedAt time.Time `json:"parked_at"`
+}
+
+func Load() ([]ParkedRule, error) {
+	data, err := state.ReadFile(storeFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading parked rules: %w", err)
+	}
+
+	var rules []ParkedRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parsing parked rules: %w", err)
+	}
+	return rules, nil
+}
+
+func save(rules []ParkedRule) error {
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFile(storeFile, data)
+}
+
+// Rules returns the parked rules as disabled entity rules. They are numbered
+// -1, -2, ... so they never collide with active rules; the numbers only hold
+// until the store changes, Find goes by the status line instead.
+func Rules(parked []ParkedRule) []entity.Rule {
+	var rules []entity.Rule
+	for i, p := range parked {
+		parsed := entity.ParseRules(p.Line)
+		if len(parsed) == 0 {
+			continue
+		}
+		rule := parsed[0]
+		rule.Number = -(i + 1)
+		rule.Disabled = true
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Find returns the parked rule a disabled entity rule was built from. The
+// status line identifies it, rules with the same line share a spec and are
+// never parked twice.
+func Find(parked []ParkedRule, rule entity.Rule) (ParkedRule, bool) {
+	if !rule.Disabled {
+		return ParkedRule{}, false
+	}
+	return lo.Find(parked, func(p ParkedRule) bool { return strings.TrimSpace(p.Line) == rule.Line })
+}
+
+// Toggle disables the active rules and enables the disabled ones.
+func Toggle(rules []entity.Rule) string {
+	active, disabled := lo.FilterReject(rules, func(rule entity.Rule, _ int) bool { return !rule.Disabled })
+	// resolve the disabled rules first, disabling changes the store
+	toEnable, err := find(disabled)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	// disable first, enabling would shift the numbers of the active rules
+	return Disable(active) + Enable(toEnable)
+}
+
+// EnableRules enables the disabled rules among rules.
+func EnableRules(rules []entity.Rule) string {
+	toEnable, err := find(lo.Filter(rules, func(rule entity.Rule, _ int) bool { return rule.Disabled }))
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return Enable(toEnable)
+}
+
+// Delete deletes active rules from ufw and drops disabled ones from the store.
+func Delete(rules []entity.Rule) string {
+	active, disabled := lo.FilterReject(rules, func(rule entity.Rule, _ int) bool { return !rule.Disabled })
+	toRemove, err := find(disabled)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return entity.DeleteRules(active) + Remove(toRemove)
+}
+
+func find(rules []entity.Rule) ([]ParkedRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	parked, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var found []ParkedRule
+	for _, rule := range rules {
+		if p, ok := Find(parked, rule); ok {
+			found = append(found, p)
+		}
+	}
+	return found, nil
+}
+
+// Disable parks the active rules and deletes them from ufw. A rule ufw created
+// for both IPv4 and IPv6 is parked once, its spec recreates both.
+func Disable(rules []entity.Rule) string {
+	rules = lo.Reject(rules, func(rule entity.Rule, _ int) bool { return rule.Disabled })
+	if len(rules) == 0 {
+		return ""
+	}
+	parked, err := Load()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	// the IPv4/IPv6 twin goes too, otherwise half of the rule stays active
+	for _, other := range entity.ParseRules(ufw.StatusNumbered()) {
+		if lo.SomeBy(rules, func(rule entity.Rule) bool { return rule.IsTwinOf(other) }) {
+			rules = append(rules, other)
+		}
+	}
+	rules = lo.UniqBy(rules, func(rule entity.Rule) int { return rule.Number })
+
+	for _, bySpec := range lo.GroupBy(rules, func(rule entity.Rule) string { return rule.Spec() }) {
+		first := lo.MinBy(bySpec, func(a, b entity.Rule) bool { return a.Number < b.Number })
+		parked = lo.Reject(parked, func(p ParkedRule, _ int) bool { return p.Spec == first.Spec() })
+		parked = append(parked, ParkedRule{
+			Spec:     first.Spec(),
+			Position: first.Number,
+			Comment:  first.Comment,
+			Line:     first.Line,
+			ParkedAt: time.Now(),
+		})
+	}
+	if err := save(parked); err != nil {
+		return "Error: saving parked rules: " + err.Error()
+	}
+	return entity.DeleteRules(rules)
+}
+
+// Enable adds the parked rules back at their former positions and drops them
+// from the store. Rules ufw refuses stay parked.
+func Enable(rules []ParkedRule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Position < rules[j].Position
+	})
+
+	var output string
+	var enabled []string
+	for _, rule := range rules {
+		inserted := entity.InsertOrAppend(rule.Position, rule.Spec)
+		if strings.Contains(inserted, "ERROR") {
+			// e.g. an IPv6 rule cannot be inserted among IPv4 rules
+			inserted = ufw.AddRule(rule.Spec)
+		}
+		output += inserted
+		if !strings.Contains(inserted, "ERROR") {
+			enabled = append(enabled, rule.Spec)
+		}
+	}
+
+	return output + remove(enabled)
+}
+
+// Remove deletes parked rules for good.
+func Remove(rules []ParkedRule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	return remove(lo.Map(rules, func(rule ParkedRule, _ int) string { return rule.Spec }))
+}
+
+func remove(specs []string) string {
+	parked, err := Load()
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	parked = lo.Reject(parked, func(p ParkedRule, _ int) bool { return lo.Contains(specs, p.Spec) })
+	if err := save(parked); err != nil {
+		return "Error: saving parked rules: " + err.Error()
+	}
+	return ""
+}
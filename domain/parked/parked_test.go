@@ -0,0 +1,37 @@
+package parked
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	ssh := ParkedRule{Spec: "allow in 22/tcp comment 'ssh'", Line: "[ 1] 22/tcp                     ALLOW IN    Anywhere                   # ssh"}
+	web := ParkedRule{Spec: "allow in 80/tcp", Line: "[ 4] 80/tcp                     ALLOW IN    Anywhere"}
+	dns := ParkedRule{Spec: "deny in 53", Line: "[ 2] 53                         DENY IN     Anywhere"}
+
+	shown := Rules([]ParkedRule{ssh, web})
+	// the store changed since the rules were shown: ssh got enabled, dns parked
+	store := []ParkedRule{dns, web}
+
+	tests := []struct {
+		name     string
+		index    int
+		wantSpec string
+		wantOk   bool
+	}{
+		{"rule still parked", 1, web.Spec, true},
+		{"rule no longer parked", 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Find(store, shown[tt.index])
+			if ok != tt.wantOk || got.Spec != tt.wantSpec {
+				t.Errorf("got %q, %v, want %q, %v", got.Spec, ok, tt.wantSpec, tt.wantOk)
+			}
+		})
+	}
+
+	active := shown[1]
+	active.Disabled = false
+	if _, ok := Find(store, active); ok {
+		t.Error("found an active rule")
+	}
+}
@@ -0,0 +1,282 @@
+package rulesfile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Paths are the iptables-restore files ufw loads around the user rules.
+var Paths = []string{
+	"/etc/ufw/before.rules",
+	"/etc/ufw/after.rules",
+	"/etc/ufw/before6.rules",
+	"/etc/ufw/after6.rules",
+}
+
+// BackupDir keeps a copy of every rules file fwtui overwrites.
+const BackupDir = "/etc/ufw/backup/rules-files"
+
+// File is an iptables-restore file kept as lines so comments and layout
+// survive editing.
+type File struct {
+	Path  string
+	lines []string
+}
+
+type Table struct {
+	Name   string
+	Chains []Chain
+	start  int // line of "*name"
+	commit int // line of "COMMIT", -1 when missing
+}
+
+type Chain struct {
+	Name   string
+	Policy string // "-" for user chains, empty when the chain is not declared
+	Rules  []Rule
+}
+
+// Rule is an "-A" line, possibly commented out.
+type Rule struct {
+	Line      int
+	Text      string // without the comment marker
+	Commented bool
+}
+
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &File{Path: path, lines: strings.Split(string(data), "\n")}, nil
+}
+
+func (f *File) Lines() []string {
+	return f.lines
+}
+
+func (f *File) SetLines(lines []string) {
+	f.lines = lines
+}
+
+// Tables parses the file into tables and chains. Rules are grouped under their
+// chain in file order, even when chains are interleaved.
+func (f *File) Tables() []Table {
+	var tables []Table
+	var current *Table
+
+	chainIndex := func(name string) int {
+		for i, chain := range current.Chains {
+			if chain.Name == name {
+				return i
+			}
+		}
+		current.Chains = append(current.Chains, Chain{Name: name})
+		return len(current.Chains) - 1
+	}
+
+	for i, line := range f.lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "*"):
+			tables = append(tables, Table{Name: trimmed[1:], start: i, commit: -1})
+			current = &tables[len(tables)-1]
+		case current == nil:
+			continue
+		case trimmed == "COMMIT":
+			current.commit = i
+			current = nil
+		case strings.HasPrefix(trimmed, ":"):
+			fields := strings.Fields(trimmed[1:])
+			if len(fields) >= 2 {
+				current.Chains[chainIndex(fields[0])].Policy = fields[1]
+			}
+		default:
+			text, commented := uncomment(trimmed)
+			fields := strings.Fields(text)
+			if len(fields) >= 2 && fields[0] == "-A" {
+				index := chainIndex(fields[1])
+				current.Chains[index].Rules = append(current.Chains[index].Rules, Rule{Line: i, Text: text, Commented: commented})
+			}
+		}
+	}
+	return tables
+}
+
+func uncomment(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#") {
+		return line, false
+	}
+	return strings.TrimSpace(strings.TrimLeft(line, "#")), true
+}
+
+// AddRule appends "-A chain args" after the last rule of the chain, or at the
+// end of the table when the chain has no rules yet.
+func (f *File) AddRule(table, chain, args string) error {
+	args = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(args), "-A "+chain))
+	if args == "" {
+		return fmt.Errorf("rule is empty")
+	}
+	line := fmt.Sprintf("-A %s %s", chain, args)
+
+	for _, t := range f.Tables() {
+		if t.Name != table {
+			continue
+		}
+		if t.commit == -1 {
+			return fmt.Errorf("table %s has no COMMIT", table)
+		}
+		position := t.commit
+		for _, c := range t.Chains {
+			if c.Name == chain && len(c.Rules) > 0 {
+				position = c.Rules[len(c.Rules)-1].Line + 1
+			}
+		}
+		f.lines = append(f.lines[:position:position], append([]string{line}, f.lines[position:]...)...)
+		return nil
+	}
+	return fmt.Errorf("table %s not found in %s", table, f.Path)
+}
+
+// InsertRule puts "-A chain args" in front of the first rule of the chain.
+func (f *File) InsertRule(table, chain, args string) error {
+	for _, t := range f.Tables() {
+		if t.Name != table {
+			continue
+		}
+		for _, c := range t.Chains {
+			if c.Name == chain && len(c.Rules) > 0 {
+				position := c.Rules[0].Line
+				line := fmt.Sprintf("-A %s %s", chain, strings.TrimSpace(args))
+				f.lines = append(f.lines[:position:position], append([]string{line}, f.lines[position:]...)...)
+				return nil
+			}
+		}
+		return f.AddRule(table, chain, args)
+	}
+	return fmt.Errorf("table %s not found in %s", table, f.Path)
+}
+
+// InsertRuleAfter adds "-A chain args" behind the chain's leading rules for
+// which keep is true, e.g. to stay below ufw's loopback accept.
+func (f *File) InsertRuleAfter(table, chain, args string, keep func(text string) bool) error {
+	for _, t := range f.Tables() {
+		if t.Name != table {
+			continue
+		}
+		for _, c := range t.Chains {
+			if c.Name != chain || len(c.Rules) == 0 {
+				continue
+			}
+			position := c.Rules[0].Line
+			for _, rule := range c.Rules {
+				if !rule.Commented && !keep(rule.Text) {
+					break
+				}
+				position = rule.Line + 1
+			}
+			line := fmt.Sprintf("-A %s %s", chain, strings.TrimSpace(args))
+			f.lines = append(f.lines[:position:position], append([]string{line}, f.lines[position:]...)...)
+			return nil
+		}
+		return f.AddRule(table, chain, args)
+	}
+	return fmt.Errorf("table %s not found in %s", table, f.Path)
+}
+
+// RemoveRule deletes the rule's line.
+func (f *File) RemoveRule(rule Rule) error {
+	if err := f.checkRule(rule); err != nil {
+		return err
+	}
+	f.lines = append(f.lines[:rule.Line:rule.Line], f.lines[rule.Line+1:]...)
+	return nil
+}
+
+// ToggleComment comments a rule out or back in.
+func (f *File) ToggleComment(rule Rule) error {
+	if err := f.checkRule(rule); err != nil {
+		return err
+	}
+	if rule.Commented {
+		f.lines[rule.Line] = rule.Text
+	} else {
+		f.lines[rule.Line] = "# " + rule.Text
+	}
+	return nil
+}
+
+// checkRule makes sure the rule, read from an earlier copy of the file, is
+// still on its line. Other modules and editors change these files too.
+func (f *File) checkRule(rule Rule) error {
+	if rule.Line < 0 || rule.Line >= len(f.lines) {
+		return fmt.Errorf("%s changed since it was loaded, line %d is gone", f.Path, rule.Line+1)
+	}
+	text, commented := uncomment(strings.TrimSpace(f.lines[rule.Line]))
+	if text != rule.Text || commented != rule.Commented {
+		return fmt.Errorf("%s changed since it was loaded, line %d no longer holds %q", f.Path, rule.Line+1, rule.Text)
+	}
+	return nil
+}
+
+// Validate checks the syntax with iptables-restore --test, which parses the
+// file without touching the live rules.
+func (f *File) Validate() error {
+	tmp, err := os.CreateTemp("", "fwtui-rules-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(f.content()); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	restore := "iptables-restore"
+	if strings.Contains(filepath.Base(f.Path), "6") {
+		restore = "ip6tables-restore"
+	}
+	cmd := exec.Command("sudo", restore, "--test", "--noflush", tmp.Name())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s rejected the rules: %s", restore, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Save validates the file, backs up the current version and writes it.
+func (f *File) Save() error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if err := backup(f.Path); err != nil {
+		return fmt.Errorf("backing up %s: %w", f.Path, err)
+	}
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, []byte(f.content()), info.Mode().Perm())
+}
+
+func (f *File) content() string {
+	return strings.Join(f.lines, "\n")
+}
+
+func backup(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(BackupDir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s", filepath.Base(path), time.Now().Format("2006-01-02_15-04-05"))
+	return os.WriteFile(filepath.Join(BackupDir, name), data, 0600)
+}
@@ -0,0 +1,179 @@
+package rulesfile
+
+import (
+	"strings"
+	"testing"
+)
+
+const beforeRules = `# rules.before
+*filter
+:ufw-before-input - [0:0]
+:ufw-before-output - [0:0]
+-A ufw-before-input -i lo -j ACCEPT
+-A ufw-before-output -o lo -j ACCEPT
+-A ufw-before-input -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A ufw-before-input -m conntrack --ctstate INVALID -j DROP
+COMMIT`
+
+func newFile(content string) *File {
+	return &File{Path: "before.rules", lines: strings.Split(content, "\n")}
+}
+
+// isInputAccept matches the loopback and established accepts of the input chain.
+func isInputAccept(text string) bool {
+	return strings.Contains(text, "-i lo -j ACCEPT") || strings.Contains(text, "RELATED,ESTABLISHED -j ACCEPT")
+}
+
+func TestInsertRuleAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		chain    string
+		wantLine int
+	}{
+		{"after the leading accepts", beforeRules, "ufw-before-input", 7},
+		{"no leading accept", beforeRules, "ufw-before-output", 5},
+		{"empty chain", beforeRules, "ufw-user-input", 8},
+		{"commented accept", strings.Replace(beforeRules, "-A ufw-before-input -i lo", "# -A ufw-before-input -i lo", 1), "ufw-before-input", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFile(tt.content)
+			if err := f.InsertRuleAfter("filter", tt.chain, "-j DROP", isInputAccept); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := f.lines[tt.wantLine], "-A "+tt.chain+" -j DROP"; got != want {
+				t.Errorf("line %d: got %q, want %q\n%s", tt.wantLine, got, want, strings.Join(f.lines, "\n"))
+			}
+		})
+	}
+
+	if err := newFile(beforeRules).InsertRuleAfter("nat", "PREROUTING", "-j DROP", isInputAccept); err == nil {
+		t.Error("inserted into a missing table")
+	}
+}
+
+func TestAddRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    string
+		args     string
+		wantLine int
+		wantText string
+	}{
+		{"after the last rule of the chain", "ufw-before-input", "-p tcp --dport 22 -j ACCEPT", 8, "-A ufw-before-input -p tcp --dport 22 -j ACCEPT"},
+		{"chain prefix dropped", "ufw-before-output", "-A ufw-before-output -j DROP", 6, "-A ufw-before-output -j DROP"},
+		{"empty chain at the end of the table", "ufw-user-input", "-j DROP", 8, "-A ufw-user-input -j DROP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFile(beforeRules)
+			if err := f.AddRule("filter", tt.chain, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.lines[tt.wantLine]; got != tt.wantText {
+				t.Errorf("line %d: got %q, want %q", tt.wantLine, got, tt.wantText)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name    string
+		content string
+		table   string
+		args    string
+	}{
+		{"empty rule", beforeRules, "filter", "  "},
+		{"missing table", beforeRules, "nat", "-j DROP"},
+		{"missing commit", strings.TrimSuffix(beforeRules, "COMMIT"), "filter", "-j DROP"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := newFile(tt.content).AddRule(tt.table, "ufw-before-input", tt.args); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestInsertRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    string
+		wantLine int
+	}{
+		{"before the first rule of the chain", "ufw-before-input", 4},
+		{"interleaved chain", "ufw-before-output", 5},
+		{"empty chain", "ufw-user-input", 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFile(beforeRules)
+			if err := f.InsertRule("filter", tt.chain, " -j DROP "); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := f.lines[tt.wantLine], "-A "+tt.chain+" -j DROP"; got != want {
+				t.Errorf("line %d: got %q, want %q", tt.wantLine, got, want)
+			}
+		})
+	}
+}
+
+func TestRemoveRuleAndToggleComment(t *testing.T) {
+	loaded := newFile(beforeRules)
+	rule := loaded.Tables()[0].Chains[0].Rules[1] // the RELATED,ESTABLISHED accept
+
+	changed := strings.Replace(beforeRules, "-A ufw-before-input -i lo -j ACCEPT\n", "", 1)
+	commented := strings.Replace(beforeRules, "-A ufw-before-input -m conntrack --ctstate RELATED", "# -A ufw-before-input -m conntrack --ctstate RELATED", 1)
+
+	tests := []struct {
+		name       string
+		content    string
+		rule       Rule
+		wantRemove string
+		wantToggle string
+		wantErr    bool
+	}{
+		{
+			name:       "unchanged file",
+			content:    beforeRules,
+			rule:       rule,
+			wantRemove: strings.Replace(beforeRules, rule.Text+"\n", "", 1),
+			wantToggle: commented,
+		},
+		{
+			name:       "commented rule",
+			content:    commented,
+			rule:       Rule{Line: rule.Line, Text: rule.Text, Commented: true},
+			wantRemove: strings.Replace(beforeRules, rule.Text+"\n", "", 1),
+			wantToggle: beforeRules,
+		},
+		{"line shifted", changed, rule, "", "", true},
+		{"commented since loading", commented, rule, "", "", true},
+		{"line gone", beforeRules, Rule{Line: 42, Text: rule.Text}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFile(tt.content)
+			err := f.RemoveRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("remove: got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(f.lines, "\n") != tt.wantRemove {
+				t.Errorf("remove: got\n%s", strings.Join(f.lines, "\n"))
+			}
+
+			f = newFile(tt.content)
+			err = f.ToggleComment(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toggle: got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(f.lines, "\n") != tt.wantToggle {
+				t.Errorf("toggle: got\n%s", strings.Join(f.lines, "\n"))
+			}
+			if tt.wantErr && strings.Join(f.lines, "\n") != tt.content {
+				t.Error("file changed despite the error")
+			}
+		})
+	}
+}
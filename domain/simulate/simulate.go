@@ -0,0 +1,134 @@
+package simulate
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/utils/netext"
+	"net"
+)
+
+// Packet is a hypothetical connection attempt. Empty addresses mean "not
+// specified" and only match rules without an address on that side.
+type Packet struct {
+	Direction   string // in or out
+	Interface   string
+	Source      string
+	Destination string
+	Port        int
+	Protocol    string // tcp or udp
+}
+
+type Verdict struct {
+	Action  string       // allow, deny, reject or limit
+	Rule    *entity.Rule // matching rule, nil when a policy decided
+	Reason  string
+	Skipped []entity.Rule // rules that could not be evaluated, e.g. source ports
+}
+
+func (p Packet) Validate() error {
+	if p.Direction != "in" && p.Direction != "out" {
+		return fmt.Errorf("invalid direction: %s", p.Direction)
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", p.Port)
+	}
+
+	var src, dst net.IP
+	if p.Source != "" {
+		if src = net.ParseIP(p.Source); src == nil {
+			return fmt.Errorf("invalid source IP: %s", p.Source)
+		}
+	}
+	if p.Destination != "" {
+		if dst = net.ParseIP(p.Destination); dst == nil {
+			return fmt.Errorf("invalid destination IP: %s", p.Destination)
+		}
+	}
+	if src != nil && dst != nil && (src.To4() == nil) != (dst.To4() == nil) {
+		return fmt.Errorf("source and destination must both be IPv4 or IPv6")
+	}
+	return nil
+}
+
+func (p Packet) isV6() bool {
+	for _, address := range []string{p.Source, p.Destination} {
+		if ip := net.ParseIP(address); ip != nil {
+			return ip.To4() == nil
+		}
+	}
+	return false
+}
+
+// Simulate walks the user rules in order like ufw does and falls back to the
+// default policy of the packet's direction. Traffic on the loopback interface
+// is accepted by before.rules before any user rule is consulted.
+func Simulate(p Packet, rules []entity.Rule, defaultPolicy string, apps map[string][]string) Verdict {
+	if p.Interface == "lo" {
+		return Verdict{Action: "allow", Reason: "loopback traffic is accepted by before.rules"}
+	}
+
+	var skipped []entity.Rule
+	for i, rule := range rules {
+		if rule.Direction != p.Direction || rule.V6 != p.isV6() {
+			continue
+		}
+		if rule.From.Ports != "" || rule.From.App != "" {
+			skipped = append(skipped, rule)
+			continue
+		}
+		if matches(rule, p, apps) {
+			return Verdict{
+				Action:  rule.Action,
+				Rule:    &rules[i],
+				Reason:  fmt.Sprintf("matched rule [%d]", rule.Number),
+				Skipped: skipped,
+			}
+		}
+	}
+
+	return Verdict{
+		Action:  defaultPolicy,
+		Reason:  fmt.Sprintf("no rule matched, default %s policy applies", directionName(p.Direction)),
+		Skipped: skipped,
+	}
+}
+
+func matches(rule entity.Rule, p Packet, apps map[string][]string) bool {
+	inInterface, outInterface := rule.To.Interface, rule.From.Interface
+	if p.Direction == "in" && inInterface != "" && inInterface != p.Interface {
+		return false
+	}
+	if p.Direction == "out" && outInterface != "" && outInterface != p.Interface {
+		return false
+	}
+
+	if !addressMatches(rule.From.Address, p.Source) || !addressMatches(rule.To.Address, p.Destination) {
+		return false
+	}
+
+	if protocol := rule.From.Protocol; protocol != "" && protocol != p.Protocol {
+		return false
+	}
+	return rule.To.MatchesPort(p.Port, p.Protocol, apps)
+}
+
+func addressMatches(ruleAddress, packetAddress string) bool {
+	if ruleAddress == "" {
+		return true
+	}
+	if packetAddress == "" {
+		return false
+	}
+	network, err := netext.ParseIPOrCIDR(ruleAddress)
+	if err != nil {
+		return false
+	}
+	return network.Contains(net.ParseIP(packetAddress))
+}
+
+func directionName(direction string) string {
+	if direction == "out" {
+		return "outgoing"
+	}
+	return "incoming"
+}
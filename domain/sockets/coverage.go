@@ -0,0 +1,85 @@
+package sockets
+
+import (
+	"fwtui/domain/entity"
+	"fwtui/utils/netext"
+	"net"
+
+	"github.com/samber/lo"
+)
+
+type Verdict string
+
+const (
+	VerdictAllowed      Verdict = "allowed"
+	VerdictDenied       Verdict = "denied"
+	VerdictNotMentioned Verdict = "not mentioned"
+	VerdictLocal        Verdict = "local only"
+)
+
+type Coverage struct {
+	Verdict Verdict
+	Rule    *entity.Rule // first matching rule, nil if none
+}
+
+// Analyze finds the first incoming rule covering the socket's port,
+// protocol and address, for each IP version the socket accepts; a socket on
+// :: also accepts IPv4. Sockets bound to loopback are never exposed.
+func Analyze(socket Socket, rules []entity.Rule, apps map[string][]string) Coverage {
+	if socket.IsLoopback() {
+		return Coverage{Verdict: VerdictLocal}
+	}
+
+	coverages := lo.Map(socket.families(), func(v6 bool, _ int) Coverage {
+		return analyzeFamily(socket, v6, rules, apps)
+	})
+	// the socket is as exposed as its most open IP version
+	for _, verdict := range []Verdict{VerdictAllowed, VerdictNotMentioned} {
+		if coverage, ok := lo.Find(coverages, func(c Coverage) bool { return c.Verdict == verdict }); ok {
+			return coverage
+		}
+	}
+	return coverages[0]
+}
+
+func analyzeFamily(socket Socket, v6 bool, rules []entity.Rule, apps map[string][]string) Coverage {
+	for i, rule := range rules {
+		if rule.Direction != "in" || rule.V6 != v6 {
+			continue
+		}
+		if !rule.To.MatchesPort(socket.Port, socket.Protocol, apps) {
+			continue
+		}
+		reaches, whole := reachesSocket(rule, socket)
+		if !reaches {
+			continue
+		}
+		if rule.IsAllowing() {
+			return Coverage{Verdict: VerdictAllowed, Rule: &rules[i]}
+		}
+		if whole {
+			return Coverage{Verdict: VerdictDenied, Rule: &rules[i]}
+		}
+		// a deny for some sources, addresses or one interface leaves the rest to later rules
+	}
+
+	return Coverage{Verdict: VerdictNotMentioned}
+}
+
+// reachesSocket reports whether the rule matches traffic to the socket, and
+// whether it matches all of it.
+func reachesSocket(rule entity.Rule, socket Socket) (reaches bool, whole bool) {
+	whole = rule.To.Interface == "" && rule.From.Address == ""
+	if rule.To.Address == "" {
+		return true, whole
+	}
+	network, err := netext.ParseIPOrCIDR(rule.To.Address)
+	ip := net.ParseIP(socket.Address)
+	if err != nil || ip == nil {
+		return false, false
+	}
+	if ip.IsUnspecified() {
+		return true, false // the socket also receives traffic to other addresses
+	}
+	return network.Contains(ip), whole
+}
@@ -0,0 +1,48 @@
+package sockets
+
+import (
+	"fwtui/domain/entity"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	allow22v4 := entity.Rule{Number: 1, Action: "allow", Direction: "in", To: entity.Endpoint{Ports: "22", Protocol: "tcp"}}
+	deny22v6 := entity.Rule{Number: 2, Action: "deny", Direction: "in", To: entity.Endpoint{Ports: "22", Protocol: "tcp"}, V6: true}
+	allow22To := entity.Rule{Number: 3, Action: "allow", Direction: "in", To: entity.Endpoint{Address: "10.0.0.1", Ports: "22", Protocol: "tcp"}}
+	deny22To := entity.Rule{Number: 4, Action: "deny", Direction: "in", To: entity.Endpoint{Address: "10.0.0.1", Ports: "22", Protocol: "tcp"}}
+	deny22Eth1 := entity.Rule{Number: 5, Action: "deny", Direction: "in", To: entity.Endpoint{Ports: "22", Protocol: "tcp", Interface: "eth1"}}
+	deny22v4 := entity.Rule{Number: 6, Action: "deny", Direction: "in", To: entity.Endpoint{Ports: "22", Protocol: "tcp"}}
+
+	tests := []struct {
+		name        string
+		socket      Socket
+		rules       []entity.Rule
+		wantVerdict Verdict
+		wantRule    int
+	}{
+		{"loopback", Socket{Protocol: "tcp", Address: "127.0.0.1", Port: 22}, []entity.Rule{allow22v4}, VerdictLocal, 0},
+		{"ipv4 socket", Socket{Protocol: "tcp", Address: "0.0.0.0", Port: 22}, []entity.Rule{deny22v6, allow22v4}, VerdictAllowed, 1},
+		{"ipv6-only socket", Socket{Protocol: "tcp", V6: true, Address: "2001:db8::1", Port: 22}, []entity.Rule{allow22v4}, VerdictNotMentioned, 0},
+		{"dual-stack socket allowed over ipv4", Socket{Protocol: "tcp", V6: true, Address: "::", Port: 22}, []entity.Rule{deny22v6, allow22v4}, VerdictAllowed, 1},
+		{"dual-stack socket denied", Socket{Protocol: "tcp", V6: true, Address: "::", Port: 22}, []entity.Rule{deny22v6}, VerdictNotMentioned, 0},
+		{"ipv4-mapped socket", Socket{Protocol: "tcp", V6: true, Address: "::ffff:10.0.0.1", Port: 22}, []entity.Rule{allow22v4}, VerdictAllowed, 1},
+		{"other destination", Socket{Protocol: "tcp", Address: "10.0.0.2", Port: 22}, []entity.Rule{allow22To}, VerdictNotMentioned, 0},
+		{"same destination", Socket{Protocol: "tcp", Address: "10.0.0.1", Port: 22}, []entity.Rule{deny22To, allow22v4}, VerdictDenied, 4},
+		{"destination of a wildcard socket", Socket{Protocol: "tcp", Address: "0.0.0.0", Port: 22}, []entity.Rule{deny22To, allow22To}, VerdictAllowed, 3},
+		{"deny on one interface", Socket{Protocol: "tcp", Address: "0.0.0.0", Port: 22}, []entity.Rule{deny22Eth1, allow22v4}, VerdictAllowed, 1},
+		{"deny everywhere", Socket{Protocol: "tcp", Address: "0.0.0.0", Port: 22}, []entity.Rule{deny22Eth1, deny22v4}, VerdictDenied, 6},
+		{"other port", Socket{Protocol: "tcp", Address: "0.0.0.0", Port: 80}, []entity.Rule{allow22v4}, VerdictNotMentioned, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(tt.socket, tt.rules, nil)
+			var gotRule int
+			if got.Rule != nil {
+				gotRule = got.Rule.Number
+			}
+			if got.Verdict != tt.wantVerdict || gotRule != tt.wantRule {
+				t.Errorf("got %s by rule %d, want %s by rule %d", got.Verdict, gotRule, tt.wantVerdict, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package sockets
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Socket struct {
+	Protocol string // tcp or udp
+	V6       bool
+	Address  string
+	Port     int
+	Inode    string
+	Process  string
+	PID      int
+}
+
+const (
+	tcpStateListen      = "0A"
+	udpStateUnconnected = "07"
+)
+
+// LoadSockets reads /proc/net/{tcp,tcp6,udp,udp6} and returns every listening
+// socket, resolving its owning process through /proc/*/fd.
+func LoadSockets() ([]Socket, error) {
+	sources := []struct {
+		file     string
+		protocol string
+		v6       bool
+		state    string
+	}{
+		{"/proc/net/tcp", "tcp", false, tcpStateListen},
+		{"/proc/net/tcp6", "tcp", true, tcpStateListen},
+		{"/proc/net/udp", "udp", false, udpStateUnconnected},
+		{"/proc/net/udp6", "udp", true, udpStateUnconnected},
+	}
+
+	var sockets []Socket
+	for _, source := range sources {
+		parsed, err := parseProcNet(source.file, source.protocol, source.v6, source.state)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // e.g. IPv6 disabled
+			}
+			return nil, err
+		}
+		sockets = append(sockets, parsed...)
+	}
+
+	processes := socketProcesses()
+	for i, s := range sockets {
+		if p, ok := processes[s.Inode]; ok {
+			sockets[i].PID = p.pid
+			sockets[i].Process = p.name
+		}
+	}
+
+	sort.SliceStable(sockets, func(i, j int) bool {
+		if sockets[i].Port != sockets[j].Port {
+			return sockets[i].Port < sockets[j].Port
+		}
+		if sockets[i].Protocol != sockets[j].Protocol {
+			return sockets[i].Protocol < sockets[j].Protocol
+		}
+		return !sockets[i].V6 && sockets[j].V6
+	})
+
+	return sockets, nil
+}
+
+// IsLoopback reports whether the socket is only reachable from the host itself.
+func (s Socket) IsLoopback() bool {
+	ip := net.ParseIP(s.Address)
+	return ip != nil && ip.IsLoopback()
+}
+
+// families returns the IP versions the socket accepts, false for IPv4. IPv6
+// sockets on :: take IPv4 connections too unless net.ipv6.bindv6only is set,
+// which is rare.
+func (s Socket) families() []bool {
+	ip := net.ParseIP(s.Address)
+	switch {
+	case !s.V6 || (ip != nil && ip.To4() != nil): // IPv4-mapped
+		return []bool{false}
+	case ip != nil && ip.IsUnspecified():
+		return []bool{false, true}
+	}
+	return []bool{true}
+}
+
+func (s Socket) ProtocolName() string {
+	if s.V6 {
+		return s.Protocol + "6"
+	}
+	return s.Protocol
+}
+
+func parseProcNet(path, protocol string, v6 bool, state string) ([]Socket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		address, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		_, remotePort, err := parseHexAddress(fields[2])
+		if err != nil || remotePort != 0 {
+			continue // connected udp socket
+		}
+
+		sockets = append(sockets, Socket{
+			Protocol: protocol,
+			V6:       v6,
+			Address:  address,
+			Port:     port,
+			Inode:    fields[9],
+		})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseHexAddress decodes "0100007F:0016" into ("127.0.0.1", 22). The kernel
+// prints the address as 32-bit words in host (little-endian) byte order.
+func parseHexAddress(s string) (string, int, error) {
+	hexIP, hexPort, found := strings.Cut(s, ":")
+	if !found {
+		return "", 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port: %s", hexPort)
+	}
+
+	raw, err := hex.DecodeString(hexIP)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid ip: %s", hexIP)
+	}
+
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for b := 0; b < 4; b++ {
+			ip[word+b] = raw[word+3-b]
+		}
+	}
+
+	return ip.String(), int(port), nil
+}
+
+type process struct {
+	pid  int
+	name string
+}
+
+// socketProcesses maps socket inodes to the process holding them open.
+func socketProcesses() map[string]process {
+	processes := map[string]process{}
+
+	fdDirs, _ := filepath.Glob("/proc/[0-9]*/fd")
+	for _, fdDir := range fdDirs {
+		pidDir := filepath.Dir(fdDir)
+		pid, err := strconv.Atoi(filepath.Base(pidDir))
+		if err != nil {
+			continue
+		}
+
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // process exited or is not accessible
+		}
+
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, seen := processes[inode]; seen {
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			processes[inode] = process{pid: pid, name: name}
+		}
+	}
+
+	return processes
+}
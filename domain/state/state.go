@@ -0,0 +1,24 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir holds files fwtui keeps between runs.
+const Dir = "/var/lib/fwtui"
+
+func Path(name string) string {
+	return filepath.Join(Dir, name)
+}
+
+func WriteFile(name string, data []byte) error {
+	if err := os.MkdirAll(Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(Path(name), data, 0644)
+}
+
+func ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(Path(name))
+}
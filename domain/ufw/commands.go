@@ -14,6 +14,10 @@ func StatusNumbered() string {
 	return oscmd.RunCommand("sudo ufw status numbered")
 }
 
+func Show(report string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo ufw show %s", report))
+}
+
 func Reset() string {
 	return oscmd.RunCommand("yes | sudo ufw reset")
 }
@@ -25,17 +29,22 @@ func Disable() string {
 	return oscmd.RunCommand("sudo ufw disable")
 }
 
-func EnableLogging() string {
-	return oscmd.RunCommand("sudo ufw logging on")
-}
-func DisableLogging() string {
-	return oscmd.RunCommand("sudo ufw logging off")
+func SetLogging(level string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo ufw logging %s", level))
 }
 
 func DeleteRuleByNumber(num int) string {
 	return oscmd.RunCommand(fmt.Sprintf("yes | sudo ufw delete %d", num))
 }
 
+func AddRule(spec string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo ufw %s", spec))
+}
+
+func InsertRule(position int, spec string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo ufw insert %d %s", position, spec))
+}
+
 func LoadProfile(name string) string {
 	return oscmd.RunCommand(fmt.Sprintf("sudo ufw app update \"%s\"", name))
 }
@@ -85,3 +94,27 @@ echo "UFW rules restored."
 `
 	return script, nil
 }
+
+func ListChain(v6 bool, chain string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo %s -L %s -v -n -x", iptablesCommand(v6), chain))
+}
+
+func ZeroChain(v6 bool, chain string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo %s -Z %s", iptablesCommand(v6), chain))
+}
+
+func iptablesCommand(v6 bool) string {
+	if v6 {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+func Reload() string {
+	return oscmd.RunCommand("sudo ufw reload")
+}
+
+// PrependRule inserts the rule in front of the rules of its IP version.
+func PrependRule(spec string) string {
+	return oscmd.RunCommand(fmt.Sprintf("sudo ufw prepend %s", spec))
+}
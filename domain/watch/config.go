@@ -0,0 +1,190 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigPath holds the jails of `fwtui watch`; the defaults apply while it is missing.
+const ConfigPath = "/etc/fwtui/watch.json"
+
+// Jail counts the log lines matching one of its patterns per source address
+// and bans the address once MaxRetry matches fall within Window.
+type Jail struct {
+	Name     string   `json:"name"`
+	Log      string   `json:"log"`
+	Patterns []string `json:"patterns"`  // regular expressions, <ip> marks the source address
+	MaxRetry int      `json:"max_retry"` // failures within the window that trigger a ban
+	Window   string   `json:"window"`    // e.g. "10m"
+	BanTime  string   `json:"ban_time"`  // e.g. "1h" or "2d"
+}
+
+type Config struct {
+	Jails  []Jail   `json:"jails"`
+	Ignore []string `json:"ignore"` // addresses and networks that are never banned
+}
+
+func DefaultConfig() Config {
+	authLog := "/var/log/auth.log"
+	if _, err := os.Stat(authLog); err != nil {
+		if _, err := os.Stat("/var/log/secure"); err == nil {
+			authLog = "/var/log/secure" // RHEL family
+		}
+	}
+	return Config{
+		Jails: []Jail{
+			{
+				Name: "sshd",
+				Log:  authLog,
+				Patterns: []string{
+					`sshd\[\d+\]: Failed \S+ for .* from <ip> port`,
+					`sshd\[\d+\]: Invalid user .* from <ip> port`,
+					`sshd\[\d+\]: Connection closed by authenticating user .* <ip> port \d+ \[preauth\]`,
+				},
+				MaxRetry: 5,
+				Window:   "10m",
+				BanTime:  "1h",
+			},
+			{
+				Name:     "portscan",
+				Log:      "/var/log/ufw.log",
+				Patterns: []string{`\[UFW BLOCK\] .* SRC=<ip> `},
+				MaxRetry: 30,
+				Window:   "1m",
+				BanTime:  "1h",
+			},
+		},
+		Ignore: []string{"127.0.0.0/8", "::1"},
+	}
+}
+
+// LoadConfig reads ConfigPath, or returns the defaults when it does not exist.
+func LoadConfig() (Config, error) {
+	data, err := os.ReadFile(ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", ConfigPath, err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", ConfigPath, err)
+	}
+	return config, nil
+}
+
+// WriteDefaultConfig writes the defaults to ConfigPath as a starting point for editing.
+func WriteDefaultConfig() error {
+	if _, err := os.Stat(ConfigPath); err == nil {
+		return fmt.Errorf("%s already exists", ConfigPath)
+	}
+	data, err := json.MarshalIndent(DefaultConfig(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ConfigPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigPath, append(data, '\n'), 0644)
+}
+
+var jailNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,19}$`)
+
+// compiledJail is a Jail with parsed patterns and durations.
+type compiledJail struct {
+	Jail
+	patterns []*regexp.Regexp
+	window   time.Duration
+	banTime  time.Duration
+}
+
+// ipPattern matches IPv4 and IPv6 addresses, they are validated after matching.
+const ipPattern = `(?P<ip>[0-9a-fA-F:.]+)`
+
+func (j Jail) compile() (compiledJail, error) {
+	compiled := compiledJail{Jail: j}
+	if !jailNameRegex.MatchString(j.Name) {
+		return compiled, fmt.Errorf("jail %q: names use lowercase letters, digits, - and _ (at most 20)", j.Name)
+	}
+	if j.Log == "" || len(j.Patterns) == 0 {
+		return compiled, fmt.Errorf("jail %s: log and patterns are required", j.Name)
+	}
+	if j.MaxRetry < 1 {
+		return compiled, fmt.Errorf("jail %s: max_retry must be at least 1", j.Name)
+	}
+
+	var err error
+	if compiled.window, err = time.ParseDuration(j.Window); err != nil || compiled.window <= 0 {
+		return compiled, fmt.Errorf("jail %s: invalid window %q", j.Name, j.Window)
+	}
+	if compiled.banTime, err = parseBanTime(j.BanTime); err != nil {
+		return compiled, fmt.Errorf("jail %s: %w", j.Name, err)
+	}
+
+	for _, pattern := range j.Patterns {
+		if !strings.Contains(pattern, "<ip>") {
+			return compiled, fmt.Errorf("jail %s: pattern %q has no <ip>", j.Name, pattern)
+		}
+		regex, err := regexp.Compile(strings.Replace(pattern, "<ip>", ipPattern, 1))
+		if err != nil {
+			return compiled, fmt.Errorf("jail %s: %w", j.Name, err)
+		}
+		compiled.patterns = append(compiled.patterns, regex)
+	}
+	return compiled, nil
+}
+
+// parseBanTime reads durations such as "90m", "1h" or "2d".
+func parseBanTime(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid ban_time %q", s)
+	}
+	return d, nil
+}
+
+// match returns the source address of a line matching one of the patterns.
+func (j compiledJail) match(line string) (netip.Addr, bool) {
+	for _, pattern := range j.patterns {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		addr, err := netip.ParseAddr(match[pattern.SubexpIndex("ip")])
+		if err == nil {
+			return addr.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func parseIgnore(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore entry %q", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
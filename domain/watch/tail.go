@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// tailer follows a log file like `tail -F`: it starts at the end, reads lines
+// appended since the last poll and reopens the file after logrotate.
+type tailer struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial string // last line without its newline yet
+	started bool
+}
+
+func newTailer(path string) *tailer {
+	return &tailer{path: path}
+}
+
+// poll returns the complete lines written since the previous call.
+func (t *tailer) poll() ([]string, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.started = true // everything in the file still to come is new
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if t.file != nil && (!os.SameFile(info, t.info) || info.Size() < t.offset) {
+		// rotated or truncated, what is left in the old file is lost
+		t.close()
+	}
+	if t.file == nil {
+		if err := t.open(info); err != nil {
+			return nil, err
+		}
+	}
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+
+	data := make([]byte, info.Size()-t.offset)
+	n, err := t.file.ReadAt(data, t.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	t.offset += int64(n)
+
+	text := t.partial + string(data[:n])
+	lines := strings.Split(text, "\n")
+	t.partial = lines[len(lines)-1]
+	return lines[:len(lines)-1], nil
+}
+
+func (t *tailer) open(info os.FileInfo) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	t.file = file
+	t.info = info
+	t.partial = ""
+	t.offset = 0
+	if !t.started {
+		// old entries were handled by a previous run or are too old to matter
+		t.offset = info.Size()
+		t.started = true
+	}
+	return nil
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
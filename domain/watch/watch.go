@@ -0,0 +1,198 @@
+package watch
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/expiry"
+	"fwtui/domain/ufw"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	pollInterval   = time.Second
+	expiryInterval = 30 * time.Second
+)
+
+// Ban is an active deny rule added by `fwtui watch`, tagged "[ban:<jail>]"
+// and lifted through its expiry tag.
+type Ban struct {
+	Rule      entity.Rule
+	Jail      string
+	Address   string
+	ExpiresAt time.Time
+}
+
+// Bans returns the ban rules among rules.
+func Bans(rules []entity.Rule) []Ban {
+	var bans []Ban
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		for _, tag := range rule.GroupTags() {
+			if tag.Key != "ban" {
+				continue
+			}
+			at, _ := rule.ExpiresAt()
+			bans = append(bans, Ban{Rule: rule, Jail: tag.Value, Address: rule.From.Address, ExpiresAt: at})
+			break
+		}
+	}
+	return bans
+}
+
+// Unban deletes the ban rules right away. `fwtui watch` inserts and lifts
+// bans all the time, so the rules are looked up again by jail and address
+// instead of trusting the rule numbers of bans.
+func Unban(bans []Ban) string {
+	current := Bans(entity.ParseRules(ufw.StatusNumbered()))
+	rules := lo.FilterMap(current, func(ban Ban, _ int) (entity.Rule, bool) {
+		return ban.Rule, lo.ContainsBy(bans, func(wanted Ban) bool {
+			return wanted.Jail == ban.Jail && wanted.Address == ban.Address
+		})
+	})
+	if len(rules) == 0 {
+		return "Ban already lifted"
+	}
+	return entity.DeleteRules(rules)
+}
+
+// Watcher counts failures per jail and source address and bans offenders.
+type Watcher struct {
+	jails    []compiledJail
+	tailers  []*tailer
+	ignore   []netip.Prefix
+	failures map[string]map[netip.Addr][]time.Time // jail name -> address -> failure times
+	logf     func(format string, args ...any)
+}
+
+func NewWatcher(config Config, logf func(format string, args ...any)) (*Watcher, error) {
+	w := &Watcher{failures: map[string]map[netip.Addr][]time.Time{}, logf: logf}
+	if len(config.Jails) == 0 {
+		return nil, fmt.Errorf("no jails configured")
+	}
+	for _, jail := range config.Jails {
+		compiled, err := jail.compile()
+		if err != nil {
+			return nil, err
+		}
+		w.jails = append(w.jails, compiled)
+		w.tailers = append(w.tailers, newTailer(jail.Log))
+		w.failures[jail.Name] = map[netip.Addr][]time.Time{}
+	}
+	var err error
+	if w.ignore, err = parseIgnore(config.Ignore); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run follows the logs until stop is closed, lifting expired bans on the way.
+func (w *Watcher) Run(stop <-chan struct{}) {
+	for _, jail := range w.jails {
+		w.logf("watching %s for %s: %d failures within %s ban for %s", jail.Log, jail.Name, jail.MaxRetry, jail.window, jail.banTime)
+	}
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	lift := time.NewTicker(expiryInterval)
+	defer lift.Stop()
+	w.liftExpired()
+
+	for {
+		select {
+		case <-stop:
+			for _, t := range w.tailers {
+				t.close()
+			}
+			return
+		case now := <-poll.C:
+			for i, t := range w.tailers {
+				lines, err := t.poll()
+				if err != nil {
+					w.logf("%s: %v", t.path, err)
+					continue
+				}
+				for _, line := range lines {
+					w.handleLine(w.jails[i], line, now)
+				}
+			}
+		case now := <-lift.C:
+			w.liftExpired()
+			w.forget(now)
+		}
+	}
+}
+
+func (w *Watcher) handleLine(jail compiledJail, line string, now time.Time) {
+	addr, ok := jail.match(line)
+	if !ok || w.ignored(addr) {
+		return
+	}
+
+	failures := w.failures[jail.Name]
+	recent := lo.Filter(failures[addr], func(at time.Time, _ int) bool {
+		return now.Sub(at) < jail.window
+	})
+	recent = append(recent, now)
+	if len(recent) < jail.MaxRetry {
+		failures[addr] = recent
+		return
+	}
+
+	delete(failures, addr)
+	w.ban(jail, addr, now)
+}
+
+func (w *Watcher) ignored(addr netip.Addr) bool {
+	return lo.ContainsBy(w.ignore, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+}
+
+func (w *Watcher) ban(jail compiledJail, addr netip.Addr, now time.Time) {
+	rules := entity.ParseRules(ufw.StatusNumbered())
+	if lo.ContainsBy(Bans(rules), func(ban Ban) bool { return ban.Address == addr.String() }) {
+		return
+	}
+
+	until := now.Add(jail.banTime)
+	comment := entity.WithExpiry(fmt.Sprintf("[ban:%s]", jail.Name), until)
+	spec := fmt.Sprintf("deny from %s comment '%s'", addr, comment)
+
+	var output string
+	if addr.Is6() {
+		// ufw only inserts IPv6 rules in front of other IPv6 rules
+		output = ufw.PrependRule(spec)
+	} else {
+		output = entity.InsertOrAppend(1, spec)
+	}
+	if strings.Contains(output, "ERROR") {
+		w.logf("%s: banning %s failed: %s", jail.Name, addr, strings.TrimSpace(output))
+		return
+	}
+	w.logf("%s: banned %s until %s", jail.Name, addr, until.Local().Format("2006-01-02 15:04"))
+}
+
+// forget drops addresses without failures inside their jail's window.
+func (w *Watcher) forget(now time.Time) {
+	for _, jail := range w.jails {
+		for addr, times := range w.failures[jail.Name] {
+			if now.Sub(times[len(times)-1]) >= jail.window {
+				delete(w.failures[jail.Name], addr)
+			}
+		}
+	}
+}
+
+func (w *Watcher) liftExpired() {
+	expired, output := expiry.RemoveExpired(time.Now())
+	for _, rule := range expired {
+		w.logf("lifted expired rule: %s", rule.Line)
+	}
+	if strings.Contains(output, "Error") {
+		w.logf("%s", strings.TrimSpace(output))
+	}
+}
@@ -2,21 +2,37 @@ package main
 
 import (
 	"fmt"
+	dockerdomain "fwtui/domain/docker"
+	"fwtui/domain/expiry"
+	geoipdomain "fwtui/domain/geoip"
 	"fwtui/domain/notification"
 	"fwtui/domain/ufw"
+	"fwtui/domain/watch"
+	"fwtui/modules/bans"
+	"fwtui/modules/blocklist"
 	"fwtui/modules/createrule"
 	"fwtui/modules/defaultpolicies"
+	"fwtui/modules/docker"
+	"fwtui/modules/geoip"
+	"fwtui/modules/groups"
+	"fwtui/modules/lint"
+	"fwtui/modules/listening"
+	"fwtui/modules/logging"
+	"fwtui/modules/nat"
+	"fwtui/modules/outputviewer"
 	"fwtui/modules/profiles"
+	"fwtui/modules/rules"
+	"fwtui/modules/rulesfiles"
 	"fwtui/modules/shared/confirmation"
+	"fwtui/modules/simulate"
 	"fwtui/utils/focusablelist"
-	"fwtui/utils/multiselect"
 	"fwtui/utils/teacmd"
 	"io/fs"
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -36,7 +52,21 @@ func main() {
 		log.Fatalf("ufw is not available or sudo failed: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "expire" {
+		runExpire()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "geoip-refresh" {
+		runGeoipRefresh()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		runWatch(os.Args[2:])
+		return
+	}
+
 	backup()
+	expired, _ := expiry.RemoveExpired(time.Now())
 
 	profilesModule, _ := profiles.Init()
 	m := model{
@@ -44,9 +74,12 @@ func main() {
 		showOptions:    focusablelist.FromList([]string{showRaw, showAdded, showListening, showBuiltins}),
 		view:           viewStateHome,
 		profilesModule: profilesModule,
+		rulesModule:    rules.Init(0),
 	}
-	m = m.reloadRules()
 	m = m.reloadStatus()
+	if len(expired) > 0 {
+		m.startupNotification = fmt.Sprintf("Removed %d expired rule(s)", len(expired))
+	}
 	p := tea.NewProgram(m)
 	_, err = p.Run()
 	if err != nil {
@@ -76,8 +109,8 @@ func (v viewHomeState) isProfiles() bool {
 	return v == viewStateProfiles
 }
 
-func (v viewHomeState) isDeleteRule() bool {
-	return v == viewStateDeleteRule
+func (v viewHomeState) isRules() bool {
+	return v == viewStateRules
 }
 
 func (v viewHomeState) isSetDefault() bool {
@@ -88,12 +121,72 @@ func (v viewHomeState) isShow() bool {
 	return v == viewShow
 }
 
+func (v viewHomeState) isLogging() bool {
+	return v == viewLogging
+}
+
+func (v viewHomeState) isListening() bool {
+	return v == viewListening
+}
+
+func (v viewHomeState) isOutputViewer() bool {
+	return v == viewOutput
+}
+
+func (v viewHomeState) isLint() bool {
+	return v == viewLint
+}
+
+func (v viewHomeState) isGroups() bool {
+	return v == viewGroups
+}
+
+func (v viewHomeState) isNat() bool {
+	return v == viewNat
+}
+
+func (v viewHomeState) isRulesFiles() bool {
+	return v == viewRulesFiles
+}
+
+func (v viewHomeState) isDocker() bool {
+	return v == viewDocker
+}
+
+func (v viewHomeState) isBlocklist() bool {
+	return v == viewBlocklist
+}
+
+func (v viewHomeState) isGeoip() bool {
+	return v == viewGeoip
+}
+
+func (v viewHomeState) isBans() bool {
+	return v == viewBans
+}
+
+func (v viewHomeState) isSimulate() bool {
+	return v == viewSimulate
+}
+
 const viewStateHome = "view_state_home"
 const viewStateProfiles = "profiles"
 const viewStateCreateRule = "create_rule"
-const viewStateDeleteRule = "delete_rule"
+const viewStateRules = "rules"
 const viewSetDefault = "set_default"
 const viewShow = "show_menu"
+const viewLogging = "logging"
+const viewListening = "listening"
+const viewOutput = "output"
+const viewLint = "lint"
+const viewSimulate = "simulate"
+const viewGroups = "groups"
+const viewNat = "nat"
+const viewRulesFiles = "rules_files"
+const viewDocker = "docker"
+const viewBlocklist = "blocklist"
+const viewGeoip = "geoip"
+const viewBans = "bans"
 
 // HOME MENU
 const menuResetUFW = "RESET_UFW"
@@ -101,24 +194,30 @@ const menuQuit = "QUIT"
 const menuDisableUFW = "DISABLE"
 const menuEnableUFW = "ENABLE"
 const menuCreateRule = "CREATE_RULE"
-const menuDeleteRule = "DELETE_RULE"
-const menuDisableLogging = "DISABLE_LOGGING"
-const menuEnableLogging = "ENABLE_LOGGING"
+const menuRules = "RULES"
+const menuLint = "LINT"
+const menuSimulate = "SIMULATE"
+const menuGroups = "GROUPS"
+const menuNat = "NAT"
+const menuRulesFiles = "RULES_FILES"
+const menuDocker = "DOCKER"
+const menuBlocklist = "BLOCKLIST"
+const menuGeoip = "GEOIP"
+const menuBans = "BANS"
+const menuLogging = "LOGGING"
 const menuSetDefault = "SET_DEFAULT"
 const menuProfiles = "PROFILES"
 const menuShow = "SHOW"
 
+// listOverhead is the number of lines around a list used by titles, help and notifications.
+const listOverhead = 8
+
 // show menu
 const showRaw = "Raw"
 const showAdded = "Added"
 const showListening = "Listening"
 const showBuiltins = "Builtins"
 
-type rule struct {
-	number int
-	line   string
-}
-
 type model struct {
 	menuList             *focusablelist.SelectableList[menuItem]
 	showOptions          *focusablelist.SelectableList[string]
@@ -128,23 +227,99 @@ type model struct {
 	notification         string
 	runningNotifications int
 	cmdIsRunning         bool
+	height               int
+	startupNotification  string
+	dockerBypass         bool // Docker runs without the DOCKER-USER integration
 
-	rules        multiselect.MultiSelectableList[rule]
-	deleteDialog *confirmation.ConfirmDialog
-
+	rulesModule       rules.RulesModule
+	rulesReturnView   viewHomeState // view shown when leaving the rule browser
 	ruleForm          createrule.RuleForm
 	profilesModule    profiles.ProfilesModule
 	setDefaultsModule defaultpolicies.DefaultModule
+	loggingModule     logging.LoggingModule
+	listeningModule   listening.ListeningModule
+	outputViewer      outputviewer.OutputViewer
+	lintModule        lint.LintModule
+	simulateModule    simulate.SimulateModule
+	groupsModule      groups.GroupsModule
+	natModule         nat.NatModule
+	rulesFilesModule  rulesfiles.RulesFilesModule
+	dockerModule      docker.DockerModule
+	blocklistModule   blocklist.BlocklistModule
+	geoipModule       geoip.GeoipModule
+	bansModule        bans.BansModule
 }
 
 func (m model) Init() tea.Cmd {
+	if m.startupNotification != "" {
+		return notification.CreateCmd(m.startupNotification)
+	}
 	return nil
 }
 
+// runExpire removes expired temporary rules, meant to be run from cron or a systemd timer.
+func runExpire() {
+	expired, output := expiry.RemoveExpired(time.Now())
+	for _, rule := range expired {
+		fmt.Printf("Removed expired rule: %s\n", rule.Line)
+	}
+	if strings.Contains(output, "Error") {
+		fmt.Print(output)
+		os.Exit(1)
+	}
+}
+
+// runGeoipRefresh applies the GeoIP restriction again when its database was
+// updated, meant to be run after geoipupdate.
+func runGeoipRefresh() {
+	config, err := geoipdomain.LoadConfig()
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+	if config == nil || !config.Outdated() {
+		return
+	}
+	output := geoipdomain.Refresh()
+	fmt.Print(output)
+	if strings.Contains(output, "Error") {
+		os.Exit(1)
+	}
+}
+
+// runWatch bans addresses with repeated failures in the logs until stopped,
+// meant to be run as a service. "watch init" writes the default jails to edit.
+func runWatch(args []string) {
+	if len(args) > 0 && args[0] == "init" {
+		if err := watch.WriteDefaultConfig(); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		fmt.Printf("Wrote %s\n", watch.ConfigPath)
+		return
+	}
+
+	config, err := watch.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	watcher, err := watch.NewWatcher(config, log.Printf)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	watcher.Run(stop)
+}
+
 // UPDATE
 
 type lastActionTimeUpMsg struct{}
-type rulesDeletedMsg struct{ Output string }
 
 func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m := mod
@@ -156,6 +331,17 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "ctrl+d", "ctrl+q":
 			return m, tea.Quit
 		}
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.menuList.SetHeight(msg.Height - listOverhead)
+		m.showOptions.SetHeight(msg.Height - listOverhead)
+		if !m.view.isRules() {
+			m.rulesModule, _ = m.rulesModule.UpdateRulesModule(msg)
+		}
+		if !m.view.isProfiles() {
+			// these modules outlive their views, keep their lists sized
+			m.profilesModule, _ = m.profilesModule.UpdateProfilesModule(msg)
+		}
 	}
 
 	switch msg := msg.(type) {
@@ -208,6 +394,14 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.menuList.Prev()
 				case "down", "j":
 					m.menuList.Next()
+				case "pgup":
+					m.menuList.PageUp()
+				case "pgdown":
+					m.menuList.PageDown()
+				case "home":
+					m.menuList.FocusFirst()
+				case "end":
+					m.menuList.FocusLast()
 				case "enter":
 					selected := m.menuList.Focused().action
 					switch selected {
@@ -220,17 +414,43 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					case menuEnableUFW:
 						ufw.Enable()
 						m = m.resetMenu()
-					case menuEnableLogging:
-						ufw.EnableLogging()
-						m = m.resetMenu()
-					case menuDisableLogging:
-						ufw.DisableLogging()
-						m = m.resetMenu()
+					case menuLogging:
+						m.view = viewLogging
+						m.loggingModule = logging.Init(logging.ParseLoggingLevel(m.status))
 					case menuCreateRule:
 						m.ruleForm = createrule.NewRuleForm()
 						m.view = viewStateCreateRule
-					case menuDeleteRule:
-						m.view = viewStateDeleteRule
+					case menuRules:
+						m.view = viewStateRules
+						m.rulesReturnView = viewStateHome
+						m = m.reloadRules()
+					case menuLint:
+						m.view = viewLint
+						m.lintModule = lint.Init(m.height)
+					case menuGroups:
+						m.view = viewGroups
+						m.groupsModule = groups.Init(m.height)
+					case menuNat:
+						m.view = viewNat
+						m.natModule = nat.Init(m.height)
+					case menuRulesFiles:
+						m.view = viewRulesFiles
+						m.rulesFilesModule = rulesfiles.Init(m.height)
+					case menuDocker:
+						m.view = viewDocker
+						m.dockerModule = docker.Init(m.height)
+					case menuBlocklist:
+						m.view = viewBlocklist
+						m.blocklistModule = blocklist.Init(m.height)
+					case menuGeoip:
+						m.view = viewGeoip
+						m.geoipModule = geoip.Init(m.height)
+					case menuBans:
+						m.view = viewBans
+						m.bansModule = bans.Init(m.height)
+					case menuSimulate:
+						m.view = viewSimulate
+						m.simulateModule = simulate.Init()
 					case menuSetDefault:
 						m.view = viewSetDefault
 						result := defaultpolicies.ParseUfwDefaults(m.status)
@@ -266,71 +486,24 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.ruleForm = newForm
 			return m, cmd
 
-		case m.view.isDeleteRule():
-			if m.deleteDialog != nil {
-				newDeleteDialog, _, outMsg := m.deleteDialog.UpdateDialog(msg)
-				m.deleteDialog = newDeleteDialog
-				switch outMsg {
-				case confirmation.ConfirmationDialogYes:
-					m.deleteDialog = nil
-
-					return m, teacmd.RunOsCmdAndAfter(func() string {
-						if m.rules.NoneSelected() {
-							return ufw.DeleteRuleByNumber(m.rules.FocusedIndex() + 1)
-						} else {
-							var output string
-							// we have to reverse otherwise the position of the next element for deletion changes
-							selectedSlice := m.rules.GetSelectedIndexes()
-							sort.Slice(selectedSlice, func(i, j int) bool {
-								return selectedSlice[i] > selectedSlice[j]
-							})
-
-							lo.ForEach(selectedSlice, func(i int, _ int) {
-								ufw.DeleteRuleByNumber(i + 1)
-							})
-							return output
-						}
-					}, func(s string) tea.Msg {
-						return rulesDeletedMsg{Output: s}
-					},
-					)
-
-				case confirmation.ConfirmationDialogNo:
-					m.deleteDialog = nil
-				case confirmation.ConfirmationDialogEsc:
-					m.deleteDialog = nil
+		case m.view.isRules():
+			switch msg.(type) {
+			case rules.RulesEscMsg:
+				m.view = m.rulesReturnView
+				if m.view.isLint() {
+					m.lintModule = lint.Init(m.height)
 				}
-
+				if m.view.isGroups() {
+					m.rulesModule = m.rulesModule.FilterBy("")
+					m.groupsModule = groups.Init(m.height)
+				}
+				m = m.reloadStatus()
 				return m, nil
 			}
 
-			switch msg := msg.(type) {
-			case rulesDeletedMsg:
-				m.rules.FocusFirst()
-				m = m.reloadRules()
-				return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
-
-			case tea.KeyMsg:
-				key := msg.String()
-				switch key {
-				case "up", "k":
-					m.rules.Prev()
-				case "down", "j":
-					m.rules.Next()
-				case "d":
-					if m.rules.NoneSelected() {
-						m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete this rule?")
-					} else {
-						m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete selected rules?")
-					}
-
-				case "esc":
-					m.view = viewStateHome
-					m = m.reloadStatus()
-				case " ":
-					m.rules.Toggle()
-				}
-			}
+			newModule, cmd := m.rulesModule.UpdateRulesModule(msg)
+			m.rulesModule = newModule
+			return m, cmd
 		case m.view.isProfiles():
 			switch msg.(type) {
 			case profiles.ProfilesEscMsg:
@@ -356,6 +529,19 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			newModule, cmd := m.setDefaultsModule.UpdateDefaultsModule(msg)
 			m.setDefaultsModule = newModule
 			return m, cmd
+		case m.view.isLogging():
+			switch msg := msg.(type) {
+			case logging.LoggingEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			case logging.LoggingUpdatedMsg:
+				m = m.resetMenu()
+				return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+			}
+
+			newModule, cmd := m.loggingModule.UpdateLoggingModule(msg)
+			m.loggingModule = newModule
+			return m, cmd
 		case m.view.isShow():
 			switch msg := msg.(type) {
 			case tea.KeyMsg:
@@ -367,6 +553,14 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.showOptions.Prev()
 				case "down", "j":
 					m.showOptions.Next()
+				case "pgup":
+					m.showOptions.PageUp()
+				case "pgdown":
+					m.showOptions.PageDown()
+				case "home":
+					m.showOptions.FocusFirst()
+				case "end":
+					m.showOptions.FocusLast()
 				case "enter":
 					var toShow string
 					switch m.showOptions.Focused() {
@@ -375,17 +569,140 @@ func (mod model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					case showAdded:
 						toShow = "added"
 					case showListening:
-						toShow = "listening"
+						m.listeningModule = listening.Init(m.height)
+						m.view = viewListening
+						return m, nil
 					case showBuiltins:
 						toShow = "builtins"
 					}
-					cmd := exec.Command("bash", "-c", "sudo ufw show "+toShow+" | less")
-					cmd.Stdout = os.Stdout
-					cmd.Stderr = os.Stderr
-					cmd.Stdin = os.Stdin
-					_ = cmd.Run()
+					m.outputViewer = outputviewer.New("ufw show "+toShow, ufw.Show(toShow), "sudo ufw show "+toShow, m.height)
+					m.view = viewOutput
 				}
 			}
+		case m.view.isListening():
+			switch msg.(type) {
+			case listening.ListeningEscMsg:
+				m.view = viewShow
+				m = m.reloadRules()
+				return m, nil
+			}
+
+			newModule, cmd := m.listeningModule.UpdateListeningModule(msg)
+			m.listeningModule = newModule
+			return m, cmd
+		case m.view.isOutputViewer():
+			switch msg.(type) {
+			case outputviewer.OutputViewerEscMsg:
+				m.view = viewShow
+				return m, nil
+			}
+
+			newViewer, cmd := m.outputViewer.UpdateOutputViewer(msg)
+			m.outputViewer = newViewer
+			return m, cmd
+		case m.view.isLint():
+			switch msg := msg.(type) {
+			case lint.LintEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			case lint.LintJumpToRuleMsg:
+				m.view = viewStateRules
+				m.rulesReturnView = viewLint
+				m = m.reloadRules()
+				m.rulesModule = m.rulesModule.FocusRule(msg.Number)
+				return m, nil
+			}
+
+			newModule, cmd := m.lintModule.UpdateLintModule(msg)
+			m.lintModule = newModule
+			return m, cmd
+		case m.view.isGroups():
+			switch msg := msg.(type) {
+			case groups.GroupsEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			case groups.GroupsShowRulesMsg:
+				m.view = viewStateRules
+				m.rulesReturnView = viewGroups
+				m = m.reloadRules()
+				m.rulesModule = m.rulesModule.FilterBy(msg.Filter)
+				return m, nil
+			}
+
+			newModule, cmd := m.groupsModule.UpdateGroupsModule(msg)
+			m.groupsModule = newModule
+			return m, cmd
+		case m.view.isNat():
+			switch msg.(type) {
+			case nat.NatEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.natModule.UpdateNatModule(msg)
+			m.natModule = newModule
+			return m, cmd
+		case m.view.isRulesFiles():
+			switch msg.(type) {
+			case rulesfiles.RulesFilesEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.rulesFilesModule.UpdateRulesFilesModule(msg)
+			m.rulesFilesModule = newModule
+			return m, cmd
+		case m.view.isDocker():
+			switch msg.(type) {
+			case docker.DockerEscMsg:
+				m.view = viewStateHome
+				m = m.reloadStatus()
+				return m, nil
+			}
+
+			newModule, cmd := m.dockerModule.UpdateDockerModule(msg)
+			m.dockerModule = newModule
+			return m, cmd
+		case m.view.isBlocklist():
+			switch msg.(type) {
+			case blocklist.BlocklistEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.blocklistModule.UpdateBlocklistModule(msg)
+			m.blocklistModule = newModule
+			return m, cmd
+		case m.view.isGeoip():
+			switch msg.(type) {
+			case geoip.GeoipEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.geoipModule.UpdateGeoipModule(msg)
+			m.geoipModule = newModule
+			return m, cmd
+		case m.view.isBans():
+			switch msg.(type) {
+			case bans.BansEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.bansModule.UpdateBansModule(msg)
+			m.bansModule = newModule
+			return m, cmd
+		case m.view.isSimulate():
+			switch msg.(type) {
+			case simulate.SimulateEscMsg:
+				m.view = viewStateHome
+				return m, nil
+			}
+
+			newModule, cmd := m.simulateModule.UpdateSimulateModule(msg)
+			m.simulateModule = newModule
+			return m, cmd
 		}
 	}
 	return m, nil
@@ -407,29 +724,21 @@ func (m model) resetMenu() model {
 
 func (m model) reloadStatus() model {
 	m.status = ufw.StatusVerbose()
+	m.dockerBypass = false
+	if dockerdomain.Detected() {
+		installed, _ := dockerdomain.IntegrationInstalled()
+		m.dockerBypass = !installed
+	}
 	return m
 }
 
 func (m model) reloadRules() model {
-	lines := strings.Split(ufw.StatusNumbered(), "\n")
-	if len(lines) < 6 {
-		m.rules.SetItems([]rule{})
-		return m
-	}
-
-	lines = lines[4:(len(lines) - 2)]
-	rules := lo.Map(lines, func(line string, index int) rule {
-		return rule{
-			number: index,
-			line:   line,
-		}
-	})
-	m.rules.SetItems(rules)
+	m.rulesModule = m.rulesModule.Reload()
 	return m
 }
 
 func buildMenu() []menuItem {
-	enabled, loggingOn := getStatus()
+	enabled, loggingLevel := getStatus()
 
 	items := []menuItem{}
 
@@ -438,15 +747,22 @@ func buildMenu() []menuItem {
 		items = append(items, menuItem{"Set defaults", menuSetDefault})
 		items = append(items,
 			menuItem{"Profiles", menuProfiles},
+			menuItem{"Rules", menuRules},
+			menuItem{"Rule groups", menuGroups},
+			menuItem{"Check rules", menuLint},
+			menuItem{"Simulate packet", menuSimulate},
+			menuItem{"Blocklists", menuBlocklist},
+			menuItem{"GeoIP", menuGeoip},
+			menuItem{"Bans", menuBans},
+			menuItem{"Port forwarding (NAT)", menuNat},
+			menuItem{"Before/after rules", menuRulesFiles},
 			menuItem{"Create rule", menuCreateRule},
-			menuItem{"Delete rule", menuDeleteRule},
 			menuItem{"Show", menuShow},
 		)
-		if loggingOn {
-			items = append(items, menuItem{"Disable logging", menuDisableLogging})
-		} else {
-			items = append(items, menuItem{"Enable logging", menuEnableLogging})
+		if dockerdomain.Detected() {
+			items = append(items, menuItem{"Docker", menuDocker})
 		}
+		items = append(items, menuItem{fmt.Sprintf("Logging (%s)", loggingLevel), menuLogging})
 	} else {
 		items = append(items, menuItem{"Enable", menuEnableUFW})
 
@@ -460,16 +776,15 @@ func buildMenu() []menuItem {
 	return items
 }
 
-func getStatus() (enabled bool, loggingOn bool) {
-	lines := strings.Split(ufw.StatusVerbose(), "\n")
+func getStatus() (enabled bool, loggingLevel logging.Level) {
+	status := ufw.StatusVerbose()
+	lines := strings.Split(status, "\n")
 	for _, line := range lines {
 		if strings.HasPrefix(line, "Status: active") {
 			enabled = true
 		}
-		if strings.HasPrefix(line, "Logging:") && strings.Contains(line, "on") {
-			loggingOn = true
-		}
 	}
+	loggingLevel = logging.ParseLoggingLevel(status)
 	return
 }
 
@@ -488,35 +803,51 @@ func (m model) View() string {
 			return m.resetDialog.ViewDialog()
 		}
 		left := renderMenu(m.menuList)
-		right := strings.Split(m.status, "\n")
+		right := renderSummary(m.status, m.rulesModule.Count())
+		if m.dockerBypass {
+			right = append(right, "", "Warning: Docker published ports bypass ufw (see Docker)")
+		}
 		output = renderTwoColumns(left, right)
 	case m.view.isCreateRule():
 		output = m.ruleForm.ViewCreateRule()
-	case m.view.isDeleteRule():
-		if m.deleteDialog != nil {
-			return m.deleteDialog.ViewDialog()
-		}
-		lines := []string{"Focus rule to delete:"}
-		m.rules.ForEach(func(rule rule, index int, isFocused, isSelected bool) {
-			focusedPrefix := lo.Ternary(isFocused, ">", " ")
-			selectedPrefix := lo.Ternary(isSelected, "*", " ")
-			prefix := focusedPrefix + selectedPrefix
-			lines = append(lines, fmt.Sprintf("%s %s", prefix, rule.line))
-		})
-		output = strings.Join(lines, "\n")
-		output += "\n\n↑↓ to navigate, d to delete, Space to select, Esc to cancel"
+	case m.view.isRules():
+		output = m.rulesModule.ViewRules()
 	case m.view.isProfiles():
 		output = m.profilesModule.ViewProfiles()
 	case m.view.isSetDefault():
 		output = m.setDefaultsModule.ViewSetDefaults()
+	case m.view.isLogging():
+		output = m.loggingModule.ViewLogging()
+	case m.view.isListening():
+		output = m.listeningModule.ViewListening()
+	case m.view.isOutputViewer():
+		output = m.outputViewer.ViewOutputViewer()
+	case m.view.isLint():
+		output = m.lintModule.ViewLint()
+	case m.view.isGroups():
+		output = m.groupsModule.ViewGroups()
+	case m.view.isNat():
+		output = m.natModule.ViewNat()
+	case m.view.isRulesFiles():
+		output = m.rulesFilesModule.ViewRulesFiles()
+	case m.view.isDocker():
+		output = m.dockerModule.ViewDocker()
+	case m.view.isBlocklist():
+		output = m.blocklistModule.ViewBlocklist()
+	case m.view.isGeoip():
+		output = m.geoipModule.ViewGeoip()
+	case m.view.isBans():
+		output = m.bansModule.ViewBans()
+	case m.view.isSimulate():
+		output = m.simulateModule.ViewSimulate()
 	case m.view.isShow():
-		lines := []string{"Select show type:"}
-		m.showOptions.ForEach(func(item string, index int, isFocused bool) {
+		lines := []string{"Select show type: " + m.showOptions.PositionIndicator()}
+		m.showOptions.ForEachVisible(func(item string, index int, isFocused bool) {
 			focusedPrefix := lo.Ternary(isFocused, ">", " ")
 			lines = append(lines, fmt.Sprintf("%s %s", focusedPrefix, item))
 		})
 		output = strings.Join(lines, "\n")
-		output += "\n\n↑↓ to navigate, Enter to confirm, q to exit selection, Esc to cancel"
+		output += "\n\n↑↓ to navigate, Enter to confirm, Esc to cancel"
 	}
 
 	output += "\n\n" + m.notification
@@ -525,14 +856,27 @@ func (m model) View() string {
 
 func renderMenu(menu *focusablelist.SelectableList[menuItem]) []string {
 	var lines []string
-	lines = append(lines, "", "UFW Firewall Menu:", "")
-	menu.ForEach(func(item menuItem, index int, isSelected bool) {
+	lines = append(lines, "", "UFW Firewall Menu: "+menu.PositionIndicator(), "")
+	menu.ForEachVisible(func(item menuItem, index int, isSelected bool) {
 		prefix := lo.Ternary(isSelected, ">", " ")
 		lines = append(lines, fmt.Sprintf("%s %s", prefix, item.title))
 	})
 	return lines
 }
 
+// renderSummary keeps the header of `ufw status verbose` (status, logging,
+// defaults) and replaces its rule table with a count, the rules live in the Rules view.
+func renderSummary(status string, ruleCount int) []string {
+	lines := []string{""}
+	for _, line := range strings.Split(status, "\n") {
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return append(lines, "", fmt.Sprintf("Rules: %d (open Rules to browse)", ruleCount))
+}
+
 func renderTwoColumns(left []string, right []string) string {
 	var b strings.Builder
 	maxLines := max(len(left), len(right))
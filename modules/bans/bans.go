@@ -0,0 +1,140 @@
+package bans
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/ufw"
+	"fwtui/domain/watch"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	"fwtui/utils/teacmd"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, jails, help and notifications.
+const listOverhead = 10
+
+type BansModule struct {
+	bans        *focusablelist.SelectableList[watch.Ban]
+	config      watch.Config
+	configErr   error
+	unbanDialog *confirmation.ConfirmDialog
+	height      int
+}
+
+func Init(height int) BansModule {
+	return BansModule{height: height}.load()
+}
+
+func (m BansModule) load() BansModule {
+	m.bans = focusablelist.FromList(watch.Bans(entity.ParseRules(ufw.StatusNumbered())))
+	m.config, m.configErr = watch.LoadConfig()
+	if m.height > 0 {
+		m.bans.SetHeight(m.height - listOverhead - len(m.config.Jails))
+	}
+	return m
+}
+
+// UPDATE
+
+type BansEscMsg struct{}
+
+type bansChangedMsg struct{ Output string }
+
+func (mod BansModule) UpdateBansModule(msg tea.Msg) (BansModule, tea.Cmd) {
+	m := mod
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.bans.SetHeight(m.height - listOverhead - len(m.config.Jails))
+		return m, nil
+	case bansChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	}
+
+	if m.unbanDialog != nil {
+		newUnbanDialog, _, outMsg := m.unbanDialog.UpdateDialog(msg)
+		m.unbanDialog = newUnbanDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.unbanDialog = nil
+			ban := m.bans.Focused()
+			return m, teacmd.RunOsCmdAndAfter(func() string {
+				return watch.Unban([]watch.Ban{ban})
+			}, func(s string) tea.Msg {
+				return bansChangedMsg{Output: s}
+			})
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.unbanDialog = nil
+		}
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "up", "k":
+		m.bans.Prev()
+	case "down", "j":
+		m.bans.Next()
+	case "pgup":
+		m.bans.PageUp()
+	case "pgdown":
+		m.bans.PageDown()
+	case "r":
+		m = m.load()
+	case "d", "delete":
+		if len(m.bans.GetItems()) > 0 {
+			m.unbanDialog = confirmation.NewConfirmDialog(fmt.Sprintf("Lift the ban of %s now?", m.bans.Focused().Address))
+		}
+	case "esc":
+		return m, func() tea.Msg {
+			return BansEscMsg{}
+		}
+	}
+	return m, nil
+}
+
+// VIEW
+
+func (m BansModule) ViewBans() string {
+	if m.unbanDialog != nil {
+		return m.unbanDialog.ViewDialog()
+	}
+
+	lines := []string{"Jails of `fwtui watch` (" + watch.ConfigPath + "):"}
+	if m.configErr != nil {
+		lines = append(lines, "  Error: "+m.configErr.Error())
+	}
+	for _, jail := range m.config.Jails {
+		lines = append(lines, fmt.Sprintf("  %-12s %d failures within %s ban for %s  %s", jail.Name, jail.MaxRetry, jail.Window, jail.BanTime, jail.Log))
+	}
+
+	lines = append(lines, "", "Active bans: "+m.bans.PositionIndicator())
+	if len(m.bans.GetItems()) == 0 {
+		lines = append(lines, "  No addresses banned.")
+	}
+	now := time.Now()
+	m.bans.ForEachVisible(func(ban watch.Ban, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		remaining := "no expiry"
+		if !ban.ExpiresAt.IsZero() {
+			remaining = entity.FormatRemaining(ban.ExpiresAt, now)
+		}
+		lines = append(lines, fmt.Sprintf("%s [%d] %-40s %-12s %s", prefix, ban.Rule.Number, ban.Address, ban.Jail, remaining))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, d lift ban, r refresh, Esc to go back"
+	return output
+}
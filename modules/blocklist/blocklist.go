@@ -0,0 +1,242 @@
+package blocklist
+
+import (
+	"fmt"
+	"fwtui/domain/blocklist"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, help and notifications.
+const listOverhead = 8
+
+type Field string
+
+const (
+	FieldName   Field = "Name"
+	FieldSource Field = "Source (file path or URL)"
+	FieldMode   Field = "Apply as"
+)
+
+type BlocklistModule struct {
+	lists        *focusablelist.SelectableList[blocklist.List]
+	loadErr      error
+	removeDialog *confirmation.ConfirmDialog
+	height       int
+
+	form *importForm // non-nil while importing a list
+}
+
+type importForm struct {
+	name          string
+	source        string
+	mode          *focusablelist.SelectableList[blocklist.Mode]
+	selectedField *focusablelist.SelectableList[Field]
+}
+
+func Init(height int) BlocklistModule {
+	return BlocklistModule{height: height}.load()
+}
+
+func (m BlocklistModule) load() BlocklistModule {
+	lists, err := blocklist.Load()
+	m.lists = focusablelist.FromList(lists)
+	m.loadErr = err
+	if m.height > 0 {
+		m.lists.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+// UPDATE
+
+type BlocklistEscMsg struct{}
+
+type blocklistChangedMsg struct{ Output string }
+
+func (mod BlocklistModule) UpdateBlocklistModule(msg tea.Msg) (BlocklistModule, tea.Cmd) {
+	m := mod
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.lists.SetHeight(m.height - listOverhead)
+		return m, nil
+	case blocklistChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	}
+
+	if m.form != nil {
+		return m.updateForm(msg)
+	}
+
+	if m.removeDialog != nil {
+		newRemoveDialog, _, outMsg := m.removeDialog.UpdateDialog(msg)
+		m.removeDialog = newRemoveDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.removeDialog = nil
+			list := m.lists.Focused()
+			return m, runCmd(func() string { return blocklist.Remove(list) })
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.removeDialog = nil
+		}
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	key := keyMsg.String()
+	switch key {
+	case "up", "k":
+		m.lists.Prev()
+	case "down", "j":
+		m.lists.Next()
+	case "n":
+		m.form = &importForm{
+			mode:          focusablelist.FromList([]blocklist.Mode{blocklist.ModeIpset, blocklist.ModeRules}),
+			selectedField: focusablelist.FromList([]Field{FieldName, FieldSource, FieldMode}),
+		}
+	case "r":
+		m = m.load()
+	case "esc":
+		return m, func() tea.Msg {
+			return BlocklistEscMsg{}
+		}
+	}
+
+	if len(m.lists.GetItems()) == 0 {
+		return m, nil
+	}
+	list := m.lists.Focused()
+	switch key {
+	case "u":
+		return m, runCmd(func() string { return blocklist.Update(list) })
+	case "d", "delete":
+		m.removeDialog = confirmation.NewConfirmDialog(fmt.Sprintf("Remove blocklist %s and its %d networks?", list.Name, list.Entries))
+	}
+	return m, nil
+}
+
+func (m BlocklistModule) updateForm(msg tea.Msg) (BlocklistModule, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	form := m.form
+	key := keyMsg.String()
+
+	var text *string
+	switch form.selectedField.Focused() {
+	case FieldName:
+		text = &form.name
+	case FieldSource:
+		text = &form.source
+	}
+
+	switch key {
+	case "up":
+		form.selectedField.Prev()
+	case "down":
+		form.selectedField.Next()
+	case "left":
+		if form.selectedField.Focused() == FieldMode {
+			form.mode.Prev()
+		}
+	case "right":
+		if form.selectedField.Focused() == FieldMode {
+			form.mode.Next()
+		}
+	case "backspace":
+		if text != nil {
+			*text = stringsext.TrimLastChar(*text)
+		}
+	case "enter":
+		list := blocklist.List{
+			Name:   strings.TrimSpace(form.name),
+			Source: strings.TrimSpace(form.source),
+			Mode:   form.mode.Focused(),
+		}
+		m.form = nil
+		return m, runCmd(func() string { return blocklist.Import(list) })
+	case "esc":
+		m.form = nil
+	default:
+		if text != nil {
+			*text += teacmd.TypedText(keyMsg)
+		}
+	}
+	return m, nil
+}
+
+func runCmd(command func() string) tea.Cmd {
+	return teacmd.RunOsCmdAndAfter(command, func(s string) tea.Msg {
+		return blocklistChangedMsg{Output: s}
+	})
+}
+
+// VIEW
+
+func (m BlocklistModule) ViewBlocklist() string {
+	if m.form != nil {
+		return m.form.view()
+	}
+	if m.removeDialog != nil {
+		return m.removeDialog.ViewDialog()
+	}
+
+	lines := []string{"Blocklists: " + m.lists.PositionIndicator()}
+	if m.loadErr != nil {
+		lines = append(lines, "  Error: "+m.loadErr.Error())
+	} else if len(m.lists.GetItems()) == 0 {
+		lines = append(lines, "  No blocklists imported.")
+	}
+
+	m.lists.ForEachVisible(func(list blocklist.List, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		lines = append(lines, fmt.Sprintf("%s %-20s %-6s %7d networks  updated %s  %s", prefix,
+			list.Name, list.Mode, list.Entries, list.UpdatedAt.Local().Format("2006-01-02 15:04"),
+			stringsext.Truncate(list.Source, 60)))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, n import list, u update from source, d remove list, r refresh, Esc to go back"
+	return output
+}
+
+func (f importForm) view() string {
+	lines := []string{"Import blocklist (plain list, CSV or Spamhaus DROP format):", ""}
+	for _, field := range f.selectedField.GetItems() {
+		var value string
+		switch field {
+		case FieldName:
+			value = f.name
+		case FieldSource:
+			value = f.source
+		case FieldMode:
+			value = string(f.mode.Focused())
+			if f.mode.Focused() == blocklist.ModeRules {
+				value += fmt.Sprintf(" (one ufw rule per network, at most %d)", blocklist.MaxRules)
+			} else {
+				value += " (one ipset dropped in before.rules)"
+			}
+		}
+		prefix := lo.Ternary(f.selectedField.Focused() == field, "> ", "  ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, field, value))
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, ←→ to change selection, type to edit, Enter to import, Esc to cancel"
+	return output
+}
@@ -2,14 +2,19 @@ package createrule
 
 import (
 	"fmt"
+	"fwtui/domain/entity"
 	"fwtui/domain/notification"
+	"fwtui/domain/ufw"
 	"fwtui/utils/focusablelist"
+	"fwtui/utils/netext"
 	"fwtui/utils/oscmd"
 	"fwtui/utils/result"
 	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/samber/lo"
@@ -28,6 +33,7 @@ const (
 	RuleDestinationIP = "DestinationIP"
 	RuleInterface     = "Interface"
 	RuleFormComment   = "Comment"
+	RuleFormExpiry    = "Expiry"
 )
 
 type RuleForm struct {
@@ -36,10 +42,14 @@ type RuleForm struct {
 	action        *focusablelist.SelectableList[Action]
 	dir           *focusablelist.SelectableList[Direction]
 	comment       string
+	expiry        string // lifetime or absolute time, empty keeps the rule forever
 	sourceIP      string
 	destinationIP string
 	interface_    *focusablelist.SelectableList[string]
 	selectedField *focusablelist.SelectableList[Field]
+
+	position int   // insert the rule at this position, 0 appends
+	replaces []int // rule numbers deleted before the new rule is added
 }
 
 func NewRuleForm() RuleForm {
@@ -54,6 +64,57 @@ func NewRuleForm() RuleForm {
 	}
 }
 
+// NewRuleFormFromRule prefills the form with an existing rule. Only simple
+// port rules fit the form; rules using app profiles, source ports or routing
+// have to be recreated by hand.
+func NewRuleFormFromRule(rule entity.Rule) result.Result[RuleForm] {
+	switch {
+	case rule.Direction == "fwd":
+		return result.Err[RuleForm](fmt.Errorf("route rules cannot be edited in the form"))
+	case rule.To.App != "" || rule.From.App != "":
+		return result.Err[RuleForm](fmt.Errorf("profile rules cannot be edited in the form"))
+	case rule.To.Ports == "" || rule.From.Ports != "":
+		return result.Err[RuleForm](fmt.Errorf("only rules with a destination port can be edited in the form"))
+	case rule.Direction == "in" && rule.To.Address != "":
+		return result.Err[RuleForm](fmt.Errorf("incoming rules with a destination address cannot be edited in the form"))
+	case rule.Direction == "out" && rule.From.Address != "":
+		return result.Err[RuleForm](fmt.Errorf("outgoing rules with a source address cannot be edited in the form"))
+	}
+
+	form := NewRuleForm()
+	form.port = rule.To.Ports
+	form.protocol.Focus(lo.Ternary(rule.To.Protocol == "", ProtocolBoth, Protocol(rule.To.Protocol)))
+	form.action.Focus(Action(rule.Action))
+	form.dir.Focus(Direction(rule.Direction))
+	form.selectedField.SetItems(fieldsForDirection(form.dir.Focused()))
+	form.comment = entity.WithoutExpiry(rule.Comment)
+	if at, ok := rule.ExpiresAt(); ok {
+		form.expiry = at.Local().Format("2006-01-02 15:04")
+	}
+	form.sourceIP = rule.From.Address
+	form.destinationIP = rule.To.Address
+
+	iface := lo.CoalesceOrEmpty(rule.To.Interface, rule.From.Interface)
+	if !lo.Contains(form.interface_.GetItems(), iface) {
+		form.interface_.SetItems(append(form.interface_.GetItems(), iface))
+	}
+	form.interface_.Focus(iface)
+
+	return result.Ok(form)
+}
+
+// InsertAt makes the form insert the rule at position instead of appending it.
+func (f RuleForm) InsertAt(position int) RuleForm {
+	f.position = position
+	return f
+}
+
+// Replacing makes the form delete the given rules before adding the new one.
+func (f RuleForm) Replacing(numbers ...int) RuleForm {
+	f.replaces = numbers
+	return f
+}
+
 // UPDATE
 
 type CreateRuleEscMsg struct{}
@@ -102,6 +163,8 @@ func (f RuleForm) UpdateRuleForm(msg tea.Msg) (RuleForm, tea.Cmd) {
 				form.port = stringsext.TrimLastChar(form.port)
 			case RuleFormComment:
 				form.comment = stringsext.TrimLastChar(form.comment)
+			case RuleFormExpiry:
+				form.expiry = stringsext.TrimLastChar(form.expiry)
 			case RuleSourceIP:
 				form.sourceIP = stringsext.TrimLastChar(form.sourceIP)
 			case RuleDestinationIP:
@@ -112,7 +175,7 @@ func (f RuleForm) UpdateRuleForm(msg tea.Msg) (RuleForm, tea.Cmd) {
 			if res.IsErr() {
 				return f, notification.CreateCmd(res.Err().Error())
 			}
-			output := oscmd.RunCommand(res.Value())
+			output := f.addReplacing(res.Value())
 			return f, tea.Batch(notification.CreateCmd(output), func() tea.Msg {
 				return CreateRuleCreatedMsg{}
 			})
@@ -123,19 +186,65 @@ func (f RuleForm) UpdateRuleForm(msg tea.Msg) (RuleForm, tea.Cmd) {
 		default:
 			switch form.selectedField.Focused() {
 			case RuleFormPort:
-				form.port += key
+				form.port += teacmd.TypedText(msg)
 			case RuleFormComment:
-				form.comment += key
+				form.comment += teacmd.TypedText(msg)
+			case RuleFormExpiry:
+				form.expiry += teacmd.TypedText(msg)
 			case RuleSourceIP:
-				form.sourceIP += key
+				form.sourceIP += teacmd.TypedText(msg)
 			case RuleDestinationIP:
-				form.destinationIP += key
+				form.destinationIP += teacmd.TypedText(msg)
 			}
 		}
 	}
 	return form, nil
 }
 
+// addReplacing runs the command after deleting the rules being replaced. ufw
+// refuses to add a duplicate, so the old rules go first; if ufw rejects the
+// new rule they are put back at their old numbers, like moving a rule does.
+func (f RuleForm) addReplacing(command string) string {
+	if len(f.replaces) == 0 {
+		return oscmd.RunCommand(f.withPosition(command))
+	}
+
+	replaced := lo.Filter(entity.ParseRules(ufw.StatusNumbered()), func(rule entity.Rule, _ int) bool {
+		return lo.Contains(f.replaces, rule.Number)
+	})
+	output := entity.DeleteRules(replaced)
+	if entity.Failed(output) {
+		return output
+	}
+	added := oscmd.RunCommand(f.withPosition(command))
+	output += added
+	if entity.Failed(added) {
+		restoredAll := true
+		// twins share a spec and come back from a single insert
+		for _, rule := range lo.UniqBy(replaced, func(rule entity.Rule) string { return rule.Spec() }) {
+			restored, ok := entity.RestoreRule(rule)
+			output += restored
+			restoredAll = restoredAll && ok
+		}
+		if restoredAll {
+			output += "The original rule was restored\n"
+		}
+	}
+	return output
+}
+
+// withPosition turns "sudo ufw <rule>" into "sudo ufw insert N <rule>". ufw
+// rejects positions past the end, so those fall back to appending.
+func (f RuleForm) withPosition(command string) string {
+	if f.position <= 0 {
+		return command
+	}
+	if f.position > len(entity.ParseRules(ufw.StatusNumbered())) {
+		return command
+	}
+	return strings.Replace(command, "sudo ufw ", fmt.Sprintf("sudo ufw insert %d ", f.position), 1)
+}
+
 func fieldsForDirection(dir Direction) []Field {
 	baseFields := []Field{
 		RuleFormPort,
@@ -143,6 +252,7 @@ func fieldsForDirection(dir Direction) []Field {
 		RuleFormAction,
 		RuleFormDir,
 		RuleFormComment,
+		RuleFormExpiry,
 	}
 
 	switch dir {
@@ -179,6 +289,11 @@ func GetActiveInterfaces() ([]string, error) {
 func (f RuleForm) ViewCreateRule() string {
 	var lines []string
 
+	if len(f.replaces) > 0 {
+		numbers := lo.Map(f.replaces, func(n int, _ int) string { return strconv.Itoa(n) })
+		lines = append(lines, fmt.Sprintf("Editing rule %s:", strings.Join(numbers, ", ")), "")
+	}
+
 	for _, field := range f.selectedField.GetItems() {
 		var value string
 		var fieldString string
@@ -199,6 +314,9 @@ func (f RuleForm) ViewCreateRule() string {
 		case RuleFormComment:
 			value = f.comment
 			fieldString = "Comment (Optional)"
+		case RuleFormExpiry:
+			value = f.expiry
+			fieldString = "Expires in/at (Optional, e.g. 4h, 2d, 2006-01-02 15:04)"
 		case RuleSourceIP:
 			value = f.sourceIP
 			fieldString = "Source IP (Optional)"
@@ -261,10 +379,8 @@ func (f RuleForm) BuildUfwCommand() result.Result[string] {
 		}
 
 		if f.sourceIP != "" {
-			if _, _, err := net.ParseCIDR(f.sourceIP); err != nil {
-				if net.ParseIP(f.sourceIP) == nil {
-					return result.Err[string](fmt.Errorf("invalid source IP: %s", f.sourceIP))
-				}
+			if _, err := netext.ParseIPOrCIDR(f.sourceIP); err != nil {
+				return result.Err[string](fmt.Errorf("invalid source IP: %s", f.sourceIP))
 			}
 			parts = append(parts, "from", f.sourceIP)
 		} else {
@@ -274,10 +390,8 @@ func (f RuleForm) BuildUfwCommand() result.Result[string] {
 	case DirectionOut:
 		parts = append(parts, "from", "any")
 		if f.destinationIP != "" {
-			if _, _, err := net.ParseCIDR(f.destinationIP); err != nil {
-				if net.ParseIP(f.destinationIP) == nil {
-					return result.Err[string](fmt.Errorf("invalid destination IP: %s", f.destinationIP))
-				}
+			if _, err := netext.ParseIPOrCIDR(f.destinationIP); err != nil {
+				return result.Err[string](fmt.Errorf("invalid destination IP: %s", f.destinationIP))
 			}
 			parts = append(parts, "to", f.destinationIP)
 		} else {
@@ -294,9 +408,17 @@ func (f RuleForm) BuildUfwCommand() result.Result[string] {
 		parts = append(parts, "port", f.port, "proto", string(f.protocol.Focused()))
 	}
 
-	// Comment (optional)
-	if f.comment != "" {
-		sanitizedComment := strings.ReplaceAll(f.comment, `'`, `'\''`)
+	// Comment (optional), carrying the expiry tag of temporary rules
+	comment := f.comment
+	if f.expiry != "" {
+		at, err := entity.ParseExpiry(f.expiry, time.Now())
+		if err != nil {
+			return result.Err[string](err)
+		}
+		comment = entity.WithExpiry(comment, at)
+	}
+	if comment != "" {
+		sanitizedComment := strings.ReplaceAll(comment, `'`, `'\''`)
 		parts = append(parts, "comment", fmt.Sprintf("'%s'", sanitizedComment))
 	}
 
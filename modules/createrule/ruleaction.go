@@ -6,6 +6,7 @@ const (
 	ActionAllow  Action = "allow"
 	ActionDeny   Action = "deny"
 	ActionReject Action = "reject"
+	ActionLimit  Action = "limit"
 )
 
-var actions = []Action{ActionAllow, ActionDeny, ActionReject}
+var actions = []Action{ActionAllow, ActionDeny, ActionReject, ActionLimit}
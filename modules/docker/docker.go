@@ -0,0 +1,217 @@
+package docker
+
+import (
+	"fmt"
+	"fwtui/domain/docker"
+	"fwtui/domain/entity"
+	"fwtui/domain/notification"
+	"fwtui/domain/ufw"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, warnings, help and notifications.
+const listOverhead = 14
+
+type DockerModule struct {
+	ports     *focusablelist.SelectableList[docker.PublishedPort]
+	rules     []entity.Rule
+	installed bool
+	loadErr   error
+	height    int
+
+	installDialog *confirmation.ConfirmDialog
+}
+
+func Init(height int) DockerModule {
+	return DockerModule{height: height}.load()
+}
+
+func (m DockerModule) load() DockerModule {
+	ports, err := docker.LoadPublishedPorts()
+	m.ports = focusablelist.FromList(ports)
+	m.loadErr = err
+	m.rules = entity.ParseRules(ufw.StatusNumbered())
+
+	installed, err := docker.IntegrationInstalled()
+	m.installed = installed
+	if m.loadErr == nil {
+		m.loadErr = err
+	}
+
+	if m.height > 0 {
+		m.ports.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+// UPDATE
+
+type DockerEscMsg struct{}
+
+type dockerChangedMsg struct{ Output string }
+
+func (mod DockerModule) UpdateDockerModule(msg tea.Msg) (DockerModule, tea.Cmd) {
+	m := mod
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.ports.SetHeight(m.height - listOverhead)
+	case dockerChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	}
+
+	if m.installDialog != nil {
+		newInstallDialog, _, outMsg := m.installDialog.UpdateDialog(msg)
+		m.installDialog = newInstallDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.installDialog = nil
+			return m, runCmd(func() string {
+				if err := docker.InstallIntegration(); err != nil {
+					return "Error: " + err.Error()
+				}
+				return "DOCKER-USER rules added to " + docker.AfterRulesPath + "\n" + ufw.Reload()
+			})
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.installDialog = nil
+		}
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up", "k":
+			m.ports.Prev()
+		case "down", "j":
+			m.ports.Next()
+		case "pgup":
+			m.ports.PageUp()
+		case "pgdown":
+			m.ports.PageDown()
+		case "r":
+			m = m.load()
+		case "i":
+			if !m.installed {
+				m.installDialog = confirmation.NewConfirmDialog(m.installQuestion())
+			}
+		case "u":
+			return m, runCmd(func() string {
+				if err := docker.RemoveIntegration(); err != nil {
+					return "Error: " + err.Error()
+				}
+				return "DOCKER-USER rules removed from " + docker.AfterRulesPath + "\n" + ufw.Reload()
+			})
+		case "esc":
+			return m, func() tea.Msg {
+				return DockerEscMsg{}
+			}
+		}
+
+		if len(m.ports.GetItems()) == 0 {
+			return m, nil
+		}
+		port := m.ports.Focused()
+		switch key {
+		case "a":
+			if port.ContainerIP == "" {
+				return m, notification.CreateCmd("Container has no IP address")
+			}
+			return m, runCmd(func() string {
+				return ufw.AddRule(port.AllowSpec())
+			})
+		case "x":
+			allowing := port.AllowingRules(m.rules)
+			if len(allowing) == 0 {
+				return m, nil
+			}
+			return m, runCmd(func() string {
+				return entity.DeleteRules(allowing)
+			})
+		}
+	}
+	return m, nil
+}
+
+// installQuestion names the published ports no ufw rule allows yet, which
+// become unreachable from outside once ufw reloads with the integration.
+func (m DockerModule) installQuestion() string {
+	var blocked []string
+	for _, port := range m.ports.GetItems() {
+		if len(port.AllowingRules(m.rules)) == 0 {
+			blocked = append(blocked, fmt.Sprintf("  %s %s:%s/%s", port.Container, port.HostAddress, port.HostPort, port.Protocol))
+		}
+	}
+	question := "Route published ports through ufw by adding DOCKER-USER rules to " + docker.AfterRulesPath + " and reloading ufw?"
+	if len(blocked) == 0 {
+		return question
+	}
+	return question + fmt.Sprintf("\n\nThese %d port(s) have no allowing rule and will be blocked from outside:\n", len(blocked)) + strings.Join(blocked, "\n")
+}
+
+func runCmd(command func() string) tea.Cmd {
+	return teacmd.RunOsCmdAndAfter(command, func(s string) tea.Msg {
+		return dockerChangedMsg{Output: s}
+	})
+}
+
+// VIEW
+
+func (m DockerModule) ViewDocker() string {
+	if m.installDialog != nil {
+		return m.installDialog.ViewDialog()
+	}
+
+	lines := []string{"Docker published ports: " + m.ports.PositionIndicator()}
+	if m.installed {
+		lines = append(lines,
+			"DOCKER-USER integration is installed: published ports are closed unless a ufw route rule allows them.")
+	} else {
+		lines = append(lines,
+			"Warning: Docker publishes ports with its own iptables rules, which bypass ufw.",
+			"Every port below is reachable from outside whatever the ufw rules say. Press i to route them through ufw.")
+	}
+	lines = append(lines, "")
+
+	if m.loadErr != nil {
+		lines = append(lines, "  Error: "+m.loadErr.Error())
+	} else if len(m.ports.GetItems()) == 0 {
+		lines = append(lines, "  No published ports.")
+	}
+
+	lines = append(lines, fmt.Sprintf("   %-24s %-22s %-18s %s", "CONTAINER", "HOST", "CONTAINER PORT", "UFW"))
+	m.ports.ForEachVisible(func(port docker.PublishedPort, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		lines = append(lines, fmt.Sprintf("%s  %-24s %-22s %-18s %s", prefix,
+			port.Container,
+			port.HostAddress+":"+port.HostPort,
+			fmt.Sprintf("%s:%s/%s", port.ContainerIP, port.ContainerPort, port.Protocol),
+			m.portStatus(port)))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, a allow port, x remove allow rules, i install / u remove DOCKER-USER integration, r refresh, Esc to go back"
+	return output
+}
+
+func (m DockerModule) portStatus(port docker.PublishedPort) string {
+	if !m.installed {
+		return "bypasses ufw"
+	}
+	allowing := port.AllowingRules(m.rules)
+	if len(allowing) == 0 {
+		return "blocked from outside"
+	}
+	numbers := lo.Map(allowing, func(rule entity.Rule, _ int) string { return fmt.Sprintf("[%d]", rule.Number) })
+	return "allowed by " + strings.Join(numbers, " ")
+}
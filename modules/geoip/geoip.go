@@ -0,0 +1,425 @@
+package geoip
+
+import (
+	"fmt"
+	"fwtui/domain/geoip"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	"fwtui/utils/multiselect"
+	"fwtui/utils/query"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the country list used by titles, help and notifications.
+const listOverhead = 8
+
+type Field string
+
+const (
+	FieldDatabase  Field = "Database (.mmdb, .csv or GeoLite2 CSV directory)"
+	FieldMode      Field = "Mode"
+	FieldProtocol  Field = "Protocol"
+	FieldPort      Field = "Port (Optional, empty restricts all ports)"
+	FieldCountries Field = "Countries"
+)
+
+type GeoipModule struct {
+	config       *geoip.Config
+	loadErr      error
+	removeDialog *confirmation.ConfirmDialog
+	height       int
+
+	form *configForm // non-nil while editing the restriction
+}
+
+type configForm struct {
+	database      string
+	mode          *focusablelist.SelectableList[geoip.Mode]
+	protocol      *focusablelist.SelectableList[string]
+	port          string
+	countries     []string // selected country codes
+	selectedField *focusablelist.SelectableList[Field]
+
+	err        error // validation error of the last apply attempt
+	picking    bool  // choosing countries
+	loading    bool
+	pickerErr  error
+	picker     multiselect.MultiSelectableList[geoip.Country]
+	filter     query.Input
+	pickerPath string // database the picker was loaded from
+}
+
+func Init(height int) GeoipModule {
+	return GeoipModule{height: height}.load()
+}
+
+func (m GeoipModule) load() GeoipModule {
+	m.config, m.loadErr = geoip.LoadConfig()
+	return m
+}
+
+func (m GeoipModule) newForm() *configForm {
+	form := &configForm{
+		database:      geoip.DefaultDatabase(),
+		mode:          focusablelist.FromList([]geoip.Mode{geoip.ModeAllow, geoip.ModeDeny}),
+		protocol:      focusablelist.FromList([]string{"tcp", "udp"}),
+		port:          "22",
+		selectedField: focusablelist.FromList([]Field{FieldDatabase, FieldMode, FieldProtocol, FieldPort, FieldCountries}),
+	}
+	if m.config != nil {
+		form.database = m.config.Database
+		form.mode.Focus(m.config.Mode)
+		form.protocol.Focus(lo.CoalesceOrEmpty(m.config.Protocol, "tcp"))
+		form.port = m.config.Port
+		form.countries = m.config.Countries
+	}
+	return form
+}
+
+func (f configForm) toConfig() geoip.Config {
+	config := geoip.Config{
+		Database:  strings.TrimSpace(f.database),
+		Mode:      f.mode.Focused(),
+		Countries: f.countries,
+		Port:      strings.TrimSpace(f.port),
+	}
+	if config.Port != "" {
+		config.Protocol = f.protocol.Focused()
+	}
+	return config
+}
+
+// UPDATE
+
+type GeoipEscMsg struct{}
+
+type geoipChangedMsg struct{ Output string }
+
+type countriesLoadedMsg struct {
+	path      string
+	countries []geoip.Country
+	err       error
+}
+
+func (mod GeoipModule) UpdateGeoipModule(msg tea.Msg) (GeoipModule, tea.Cmd) {
+	m := mod
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		if m.form != nil && m.form.picking {
+			m.form.picker.SetHeight(m.height - listOverhead)
+		}
+		return m, nil
+	case geoipChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	case countriesLoadedMsg:
+		if m.form != nil && m.form.picking {
+			m.form.setCountries(msg, m.height)
+		}
+		return m, nil
+	}
+
+	if m.form != nil {
+		if m.form.picking {
+			return m.updatePicker(msg)
+		}
+		return m.updateForm(msg)
+	}
+
+	if m.removeDialog != nil {
+		newRemoveDialog, _, outMsg := m.removeDialog.UpdateDialog(msg)
+		m.removeDialog = newRemoveDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.removeDialog = nil
+			return m, runCmd(geoip.Remove)
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.removeDialog = nil
+		}
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "e", "enter":
+		m.form = m.newForm()
+	case "f":
+		if m.config != nil {
+			return m, runCmd(geoip.Refresh)
+		}
+	case "d", "delete":
+		if m.config != nil {
+			m.removeDialog = confirmation.NewConfirmDialog("Remove the GeoIP restriction?")
+		}
+	case "esc":
+		return m, func() tea.Msg {
+			return GeoipEscMsg{}
+		}
+	}
+	return m, nil
+}
+
+func (m GeoipModule) updateForm(msg tea.Msg) (GeoipModule, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	form := m.form
+	key := keyMsg.String()
+
+	var text *string
+	switch form.selectedField.Focused() {
+	case FieldDatabase:
+		text = &form.database
+	case FieldPort:
+		text = &form.port
+	}
+
+	switch key {
+	case "up":
+		form.selectedField.Prev()
+	case "down":
+		form.selectedField.Next()
+	case "left":
+		switch form.selectedField.Focused() {
+		case FieldMode:
+			form.mode.Prev()
+		case FieldProtocol:
+			form.protocol.Prev()
+		}
+	case "right":
+		switch form.selectedField.Focused() {
+		case FieldMode:
+			form.mode.Next()
+		case FieldProtocol:
+			form.protocol.Next()
+		}
+	case "backspace":
+		if text != nil {
+			*text = stringsext.TrimLastChar(*text)
+		}
+	case "enter":
+		if form.selectedField.Focused() == FieldCountries {
+			return m, form.openPicker()
+		}
+		config := form.toConfig()
+		if err := config.Validate(); err != nil {
+			form.err = err
+			return m, nil
+		}
+		m.form = nil
+		return m, runCmd(func() string { return geoip.Apply(config) })
+	case "esc":
+		m.form = nil
+	default:
+		if text != nil {
+			*text += teacmd.TypedText(keyMsg)
+		}
+	}
+	return m, nil
+}
+
+// openPicker shows the countries of the database, loading it unless the
+// picker already holds it.
+func (f *configForm) openPicker() tea.Cmd {
+	f.picking = true
+	f.filter = query.Input{}
+	path := strings.TrimSpace(f.database)
+	if path == f.pickerPath && f.pickerErr == nil && !f.picker.IsEmpty() {
+		f.picker.ClearFilter()
+		return nil
+	}
+	f.loading = true
+	f.pickerErr = nil
+	return func() tea.Msg {
+		countries, err := geoip.Load(path)
+		return countriesLoadedMsg{path: path, countries: countries, err: err}
+	}
+}
+
+func (f *configForm) setCountries(msg countriesLoadedMsg, height int) {
+	f.loading = false
+	f.pickerErr = msg.err
+	f.pickerPath = msg.path
+	f.picker = multiselect.FromList(msg.countries)
+	if height > 0 {
+		f.picker.SetHeight(height - listOverhead)
+	}
+	for i, country := range msg.countries {
+		if slices.Contains(f.countries, country.Code) {
+			f.picker.Selected.Add(i)
+		}
+	}
+}
+
+func (m GeoipModule) updatePicker(msg tea.Msg) (GeoipModule, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	form := m.form
+	key := keyMsg.String()
+
+	if !form.loading {
+		if handled, changed := form.filter.HandleKey(keyMsg); handled {
+			if changed {
+				form.applyFilter()
+			}
+			return m, nil
+		}
+	}
+
+	switch key {
+	case "up", "k":
+		form.picker.Prev()
+	case "down", "j":
+		form.picker.Next()
+	case "pgup":
+		form.picker.PageUp()
+	case "pgdown":
+		form.picker.PageDown()
+	case "home":
+		form.picker.FocusFirst()
+	case "end":
+		form.picker.FocusLast()
+	case " ":
+		form.picker.Toggle()
+	case "enter":
+		if form.pickerErr == nil && !form.loading {
+			form.countries = lo.Map(form.picker.GetSelectedItems(), func(c geoip.Country, _ int) string { return c.Code })
+		}
+		form.picking = false
+	case "esc":
+		form.picking = false
+	}
+	return m, nil
+}
+
+func (f *configForm) applyFilter() {
+	if !f.filter.IsActive() {
+		f.picker.ClearFilter()
+		return
+	}
+	q := f.filter.Query()
+	f.picker.SetFilter(func(country geoip.Country) bool {
+		return q.Matches(func(term query.Term) bool {
+			return query.ContainsFold(country.Label(), term.Value)
+		})
+	})
+}
+
+func runCmd(command func() string) tea.Cmd {
+	return teacmd.RunOsCmdAndAfter(command, func(s string) tea.Msg {
+		return geoipChangedMsg{Output: s}
+	})
+}
+
+// VIEW
+
+func (m GeoipModule) ViewGeoip() string {
+	if m.form != nil {
+		if m.form.picking {
+			return m.form.viewPicker()
+		}
+		return m.form.view()
+	}
+	if m.removeDialog != nil {
+		return m.removeDialog.ViewDialog()
+	}
+
+	lines := []string{"GeoIP restriction:", ""}
+	switch {
+	case m.loadErr != nil:
+		lines = append(lines, "  Error: "+m.loadErr.Error())
+	case m.config == nil:
+		lines = append(lines, "  No GeoIP restriction applied.")
+	default:
+		c := m.config
+		verb := lo.Ternary(c.Mode == geoip.ModeAllow, "Only allow", "Deny")
+		target := "all traffic"
+		if c.Port != "" {
+			target = fmt.Sprintf("port %s/%s", c.Port, c.Protocol)
+		}
+		lines = append(lines,
+			fmt.Sprintf("  %s %s from: %s", verb, target, strings.Join(c.Countries, ", ")),
+			fmt.Sprintf("  %d networks, applied %s", c.Networks, c.UpdatedAt.Local().Format("2006-01-02 15:04")),
+			"  Database: "+c.Database,
+		)
+		if c.Outdated() {
+			lines = append(lines, "", "  The database was updated since, press f to refresh the networks.")
+		}
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\nEnter/e edit restriction, f refresh from database, d remove, Esc to go back"
+	return output
+}
+
+func (f configForm) view() string {
+	lines := []string{"Restrict traffic by country:", ""}
+	for _, field := range f.selectedField.GetItems() {
+		var value string
+		switch field {
+		case FieldDatabase:
+			value = f.database
+		case FieldMode:
+			value = string(f.mode.Focused())
+			if f.mode.Focused() == geoip.ModeAllow {
+				value += " (only the selected countries and private networks)"
+			} else {
+				value += " (drop the selected countries)"
+			}
+		case FieldProtocol:
+			value = f.protocol.Focused()
+		case FieldPort:
+			value = f.port
+		case FieldCountries:
+			value = lo.Ternary(len(f.countries) == 0, "none, press Enter to choose", strings.Join(f.countries, ", "))
+		}
+		prefix := lo.Ternary(f.selectedField.Focused() == field, "> ", "  ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, field, value))
+	}
+	if f.err != nil {
+		lines = append(lines, "", "Error: "+f.err.Error())
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, ←→ to change selection, type to edit, Enter to apply (or choose countries), Esc to cancel"
+	return output
+}
+
+func (f configForm) viewPicker() string {
+	switch {
+	case f.loading:
+		return "Loading " + f.database + "...\n\nEsc to go back"
+	case f.pickerErr != nil:
+		return "Error: " + f.pickerErr.Error() + "\n\nEsc to go back"
+	}
+
+	lines := []string{"Select countries: " + f.picker.PositionIndicator()}
+	if filter := f.filter.View(); filter != "" {
+		lines = append(lines, filter)
+	}
+	f.picker.ForEachVisible(func(country geoip.Country, _ int, isFocused, isSelected bool) {
+		prefix := lo.Ternary(isFocused, ">", " ")
+		check := lo.Ternary(isSelected, "[x]", "[ ]")
+		lines = append(lines, fmt.Sprintf("%s %s %-40s %6d networks", prefix, check, stringsext.Truncate(country.Label(), 40), len(country.Prefixes)))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, space to select, / to filter, Enter to confirm, Esc to cancel"
+	return output
+}
@@ -0,0 +1,211 @@
+package groups
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/parked"
+	"fwtui/domain/ufw"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, the rule preview, help and notifications.
+const listOverhead = 14
+
+// previewRules is the number of rules of the focused group shown below the list.
+const previewRules = 5
+
+type GroupsModule struct {
+	tags         *focusablelist.SelectableList[entity.Tag]
+	rules        map[entity.Tag][]entity.Rule
+	deleteDialog *confirmation.ConfirmDialog
+	height       int
+}
+
+func Init(height int) GroupsModule {
+	return GroupsModule{height: height}.load()
+}
+
+func (m GroupsModule) load() GroupsModule {
+	rules := entity.ParseRules(ufw.StatusNumbered())
+	if parkedRules, err := parked.Load(); err == nil {
+		rules = append(rules, parked.Rules(parkedRules)...)
+	}
+	groups := entity.GroupRules(rules)
+	m.tags = focusablelist.FromList(lo.Map(groups, func(group entity.RuleGroup, _ int) entity.Tag {
+		return group.Tag
+	}))
+	m.rules = lo.SliceToMap(groups, func(group entity.RuleGroup) (entity.Tag, []entity.Rule) {
+		return group.Tag, group.Rules
+	})
+	if m.height > 0 {
+		m.tags.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+func (m GroupsModule) focusedGroup() entity.RuleGroup {
+	tag := m.tags.Focused()
+	return entity.RuleGroup{Tag: tag, Rules: m.rules[tag]}
+}
+
+// UPDATE
+
+type GroupsEscMsg struct{}
+
+// GroupsShowRulesMsg asks to show the rules of a group in the rule browser.
+type GroupsShowRulesMsg struct{ Filter string }
+
+type groupsChangedMsg struct{ Output string }
+
+func (mod GroupsModule) UpdateGroupsModule(msg tea.Msg) (GroupsModule, tea.Cmd) {
+	m := mod
+
+	if m.deleteDialog != nil {
+		newDeleteDialog, _, outMsg := m.deleteDialog.UpdateDialog(msg)
+		m.deleteDialog = newDeleteDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.deleteDialog = nil
+			group := m.focusedGroup()
+			return m, teacmd.RunOsCmdAndAfter(func() string {
+				return parked.Delete(group.Rules)
+			}, func(s string) tea.Msg {
+				return groupsChangedMsg{Output: s}
+			})
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.deleteDialog = nil
+		}
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.tags.SetHeight(m.height - listOverhead)
+	case groupsChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up", "k":
+			m.tags.Prev()
+		case "down", "j":
+			m.tags.Next()
+		case "pgup":
+			m.tags.PageUp()
+		case "pgdown":
+			m.tags.PageDown()
+		case "home":
+			m.tags.FocusFirst()
+		case "end":
+			m.tags.FocusLast()
+		case "r":
+			m = m.load()
+		case "esc":
+			return m, func() tea.Msg {
+				return GroupsEscMsg{}
+			}
+		}
+
+		if len(m.tags.GetItems()) == 0 {
+			return m, nil
+		}
+		group := m.focusedGroup()
+		switch key {
+		case "enter":
+			filter := fmt.Sprintf("tag:%s:%s", group.Tag.Key, group.Tag.Value)
+			return m, func() tea.Msg {
+				return GroupsShowRulesMsg{Filter: filter}
+			}
+		case "d", "delete":
+			m.deleteDialog = confirmation.NewConfirmDialog(
+				fmt.Sprintf("Delete all %d rules tagged %s?", len(group.Rules), group.Tag))
+		case "e":
+			return m, teacmd.RunOsCmdAndAfter(func() string {
+				return lo.CoalesceOrEmpty(parked.EnableRules(group.Rules), "No disabled rules in group")
+			}, func(s string) tea.Msg {
+				return groupsChangedMsg{Output: s}
+			})
+		case "o":
+			return m, teacmd.RunOsCmdAndAfter(func() string {
+				return lo.CoalesceOrEmpty(parked.Disable(group.Rules), "No active rules in group")
+			}, func(s string) tea.Msg {
+				return groupsChangedMsg{Output: s}
+			})
+		case "w":
+			return m, teacmd.RunOsCmdAndAfter(func() string {
+				return exportGroup(group)
+			}, func(s string) tea.Msg {
+				return groupsChangedMsg{Output: s}
+			})
+		}
+	}
+	return m, nil
+}
+
+// exportGroup writes a script re-creating the group's rules to the working directory.
+func exportGroup(group entity.RuleGroup) string {
+	lines := []string{
+		"#!/bin/bash",
+		fmt.Sprintf("# ufw rules tagged %s, exported by fwtui on %s", group.Tag, time.Now().Format("2006-01-02 15:04")),
+		"set -e",
+		"",
+	}
+	for _, rule := range group.Rules {
+		lines = append(lines, lo.Ternary(rule.Disabled, "# disabled: ", "")+"ufw "+rule.Spec())
+	}
+
+	name := strings.NewReplacer(" ", "-", "/", "-").Replace(group.Tag.Key + "-" + group.Tag.Value)
+	path := fmt.Sprintf("fwtui-group-%s-%s.sh", name, time.Now().Format("2006-01-02_15-04-05"))
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0755); err != nil {
+		return fmt.Sprintf("Error exporting group: %s", err)
+	}
+	return fmt.Sprintf("Group %s exported to %s", group.Tag, path)
+}
+
+// VIEW
+
+func (m GroupsModule) ViewGroups() string {
+	if m.deleteDialog != nil {
+		return m.deleteDialog.ViewDialog()
+	}
+
+	lines := []string{"Rule groups by comment tag: " + m.tags.PositionIndicator()}
+
+	if len(m.tags.GetItems()) == 0 {
+		lines = append(lines, "  No tagged rules. Start a rule comment with a tag such as [team:web] to group it.")
+	}
+	m.tags.ForEachVisible(func(tag entity.Tag, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		disabled := lo.CountBy(m.rules[tag], func(rule entity.Rule) bool { return rule.Disabled })
+		lines = append(lines, fmt.Sprintf("%s %-32s %d rules%s", prefix, tag, len(m.rules[tag]),
+			lo.Ternary(disabled > 0, fmt.Sprintf(", %d disabled", disabled), "")))
+	})
+
+	if len(m.tags.GetItems()) > 0 {
+		group := m.focusedGroup()
+		lines = append(lines, "")
+		for _, rule := range lo.Slice(group.Rules, 0, previewRules) {
+			lines = append(lines, "  "+lo.Ternary(rule.Disabled, stringsext.Faint("[off] "+rule.Line), rule.Line))
+		}
+		if len(group.Rules) > previewRules {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(group.Rules)-previewRules))
+		}
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, Enter to browse rules, e enable group, o disable group, d delete group, w export group, r refresh, Esc to go back"
+	return output
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/lint"
+	"fwtui/domain/ufw"
+	"fwtui/utils/focusablelist"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the report used by titles, help and notifications.
+const listOverhead = 7
+
+type LintModule struct {
+	findings *focusablelist.SelectableList[lint.Finding]
+	height   int
+}
+
+func Init(height int) LintModule {
+	return LintModule{height: height}.run()
+}
+
+func (m LintModule) run() LintModule {
+	rules := entity.ParseRules(ufw.StatusNumbered())
+
+	var apps map[string][]string
+	if lo.SomeBy(rules, func(r entity.Rule) bool { return r.To.App != "" || r.From.App != "" }) {
+		installed, _ := entity.LoadInstalledProfiles()
+		apps = entity.ProfilePorts(installed)
+	}
+
+	m.findings = focusablelist.FromList(lint.Lint(rules, apps))
+	if m.height > 0 {
+		m.findings.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+// UPDATE
+
+type LintEscMsg struct{}
+
+// LintJumpToRuleMsg asks to show the rule a finding is about in the rule browser.
+type LintJumpToRuleMsg struct{ Number int }
+
+func (mod LintModule) UpdateLintModule(msg tea.Msg) (LintModule, tea.Cmd) {
+	m := mod
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.findings.SetHeight(m.height - listOverhead)
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up", "k":
+			m.findings.Prev()
+		case "down", "j":
+			m.findings.Next()
+		case "pgup":
+			m.findings.PageUp()
+		case "pgdown":
+			m.findings.PageDown()
+		case "home":
+			m.findings.FocusFirst()
+		case "end":
+			m.findings.FocusLast()
+		case "r":
+			m = m.run()
+		case "enter":
+			if len(m.findings.GetItems()) == 0 {
+				return m, nil
+			}
+			number := m.findings.Focused().Rule.Number
+			return m, func() tea.Msg {
+				return LintJumpToRuleMsg{Number: number}
+			}
+		case "esc":
+			return m, func() tea.Msg {
+				return LintEscMsg{}
+			}
+		}
+	}
+	return m, nil
+}
+
+// VIEW
+
+func (m LintModule) ViewLint() string {
+	findings := m.findings.GetItems()
+	lines := []string{fmt.Sprintf("Rule check: %d findings %s", len(findings), m.findings.PositionIndicator())}
+
+	if len(findings) == 0 {
+		lines = append(lines, "  No problems found.")
+	}
+	m.findings.ForEachVisible(func(finding lint.Finding, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		lines = append(lines, fmt.Sprintf("%s %-5s %-12s %s", prefix, fmt.Sprintf("[%d]", finding.Rule.Number), finding.Kind, finding.Message))
+	})
+
+	if len(findings) > 0 {
+		lines = append(lines, "", "  "+m.findings.Focused().Rule.Line)
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, Enter to jump to rule, r to re-run, Esc to go back"
+	return output
+}
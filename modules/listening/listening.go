@@ -0,0 +1,305 @@
+package listening
+
+import (
+	"fmt"
+	"fwtui/domain/catalog"
+	"fwtui/domain/entity"
+	"fwtui/domain/sockets"
+	"fwtui/domain/ufw"
+	"fwtui/modules/defaultpolicies"
+	"fwtui/utils/multiselect"
+	"fwtui/utils/oscmd"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+type listeningSocket struct {
+	socket   sockets.Socket
+	coverage sockets.Coverage
+}
+
+// plannedCommand is a ufw command shown in the preview before it is applied.
+type plannedCommand struct {
+	command       string
+	createProfile *entity.UFWProfile // profile file written before running the command
+}
+
+type ListeningModule struct {
+	sockets         multiselect.MultiSelectableList[listeningSocket]
+	defaultIncoming string
+	loadErr         error
+	height          int
+
+	preview []plannedCommand
+	skipped []string
+}
+
+// listOverhead is the number of lines around the list used by titles, help and notifications.
+const listOverhead = 8
+
+func Init(height int) ListeningModule {
+	return ListeningModule{height: height}.reload()
+}
+
+func (m ListeningModule) reload() ListeningModule {
+	rules := entity.ParseRules(ufw.StatusNumbered())
+
+	var apps map[string][]string
+	if lo.SomeBy(rules, func(r entity.Rule) bool { return r.To.App != "" }) {
+		installed, _ := entity.LoadInstalledProfiles()
+		apps = entity.ProfilePorts(installed)
+	}
+
+	m.defaultIncoming = defaultpolicies.ParseUfwDefaults(ufw.StatusVerbose()).
+		WithDefault(defaultpolicies.DefaultPolicies{}).Incoming
+
+	loaded, err := sockets.LoadSockets()
+	m.loadErr = err
+	m.sockets = multiselect.FromList(lo.Map(loaded, func(s sockets.Socket, _ int) listeningSocket {
+		return listeningSocket{socket: s, coverage: sockets.Analyze(s, rules, apps)}
+	}))
+	if m.height > 0 {
+		m.sockets.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+// UPDATE
+
+type ListeningEscMsg struct{}
+
+type rulesCreatedMsg struct{ Output string }
+
+func (mod ListeningModule) UpdateListeningModule(msg tea.Msg) (ListeningModule, tea.Cmd) {
+	m := mod
+
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.height = msg.Height
+		m.sockets.SetHeight(m.height - listOverhead)
+		return m, nil
+	}
+
+	if m.preview != nil {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				commands := m.preview
+				m.preview = nil
+				m.skipped = nil
+				return m, teacmd.RunOsCmdAndAfter(func() string {
+					return applyCommands(commands)
+				}, func(s string) tea.Msg {
+					return rulesCreatedMsg{Output: s}
+				})
+			case "esc":
+				m.preview = nil
+				m.skipped = nil
+			}
+		}
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case rulesCreatedMsg:
+		m = m.reload()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up", "k":
+			m.sockets.Prev()
+		case "down", "j":
+			m.sockets.Next()
+		case "pgup":
+			m.sockets.PageUp()
+		case "pgdown":
+			m.sockets.PageDown()
+		case "home":
+			m.sockets.FocusFirst()
+		case "end":
+			m.sockets.FocusLast()
+		case " ":
+			m.sockets.Toggle()
+		case "a":
+			m = m.planRules("allow")
+		case "l":
+			m = m.planRules("limit")
+		case "p":
+			m = m.planProfiles()
+		case "r":
+			m = m.reload()
+		case "esc":
+			return m, func() tea.Msg {
+				return ListeningEscMsg{}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m ListeningModule) targets() []listeningSocket {
+	if len(m.sockets.Items) == 0 {
+		return nil
+	}
+	if m.sockets.NoneSelected() {
+		return []listeningSocket{m.sockets.FocusedItem()}
+	}
+	return m.sockets.GetSelectedItems()
+}
+
+func (m ListeningModule) planRules(action string) ListeningModule {
+	var planned []plannedCommand
+	for _, target := range m.exposedTargets() {
+		command := fmt.Sprintf("sudo ufw %s %d/%s", action, target.socket.Port, target.socket.Protocol)
+		if target.socket.Process != "" {
+			command += fmt.Sprintf(" comment '%s'", strings.ReplaceAll(target.socket.Process, `'`, `'\''`))
+		}
+		planned = append(planned, plannedCommand{command: command})
+	}
+	return m.setPreview(planned)
+}
+
+func (m ListeningModule) planProfiles() ListeningModule {
+	installed, _ := entity.LoadInstalledProfiles()
+	installable, _ := catalog.InstallableProfiles()
+	candidates := append(installed, installable...)
+
+	var planned []plannedCommand
+	for _, target := range m.exposedTargets() {
+		profile, found := entity.FindProfileForPort(candidates, target.socket.Port, target.socket.Protocol)
+		if !found {
+			m.skipped = append(m.skipped, fmt.Sprintf("%s port %d: no matching profile", target.socket.ProtocolName(), target.socket.Port))
+			continue
+		}
+
+		command := plannedCommand{command: fmt.Sprintf("sudo ufw allow \"%s\"", profile.Name)}
+		if !profile.Installed {
+			command.createProfile = &profile
+		}
+		planned = append(planned, command)
+	}
+	return m.setPreview(planned)
+}
+
+// exposedTargets drops loopback sockets, which no rule can expose.
+func (m *ListeningModule) exposedTargets() []listeningSocket {
+	m.skipped = nil
+	return lo.Filter(m.targets(), func(target listeningSocket, _ int) bool {
+		if target.socket.IsLoopback() {
+			m.skipped = append(m.skipped, fmt.Sprintf("%s %s:%d: local only", target.socket.ProtocolName(), target.socket.Address, target.socket.Port))
+			return false
+		}
+		return true
+	})
+}
+
+func (m ListeningModule) setPreview(planned []plannedCommand) ListeningModule {
+	// tcp and tcp6 sockets on the same port produce the same command
+	m.preview = lo.UniqBy(planned, func(c plannedCommand) string {
+		return c.command
+	})
+	return m
+}
+
+func applyCommands(commands []plannedCommand) string {
+	var outputs []string
+	for _, c := range commands {
+		if c.createProfile != nil {
+			res := entity.CreateProfile(*c.createProfile)
+			if res.IsErr() {
+				outputs = append(outputs, res.Err().Error())
+				continue
+			}
+			outputs = append(outputs, res.Value())
+		}
+		outputs = append(outputs, strings.TrimSpace(oscmd.RunCommand(c.command)))
+	}
+	return strings.Join(outputs, "\n")
+}
+
+// VIEW
+
+func (m ListeningModule) ViewListening() string {
+	if m.loadErr != nil {
+		return fmt.Sprintf("Failed to read listening sockets: %s\n\nEsc to go back", m.loadErr)
+	}
+
+	if m.preview != nil {
+		return m.viewPreview()
+	}
+
+	lines := []string{
+		"Listening sockets: " + m.sockets.PositionIndicator(),
+		fmt.Sprintf("   %-6s %-40s %-6s %-24s %s", "PROTO", "ADDRESS", "PORT", "PROCESS", "RULES"),
+	}
+	m.sockets.ForEachVisible(func(item listeningSocket, _ int, isFocused, isSelected bool) {
+		focusedPrefix := lo.Ternary(isFocused, ">", " ")
+		selectedPrefix := lo.Ternary(isSelected, "*", " ")
+		lines = append(lines, fmt.Sprintf("%s%s %-6s %-40s %-6d %-24s %s",
+			focusedPrefix,
+			selectedPrefix,
+			item.socket.ProtocolName(),
+			item.socket.Address,
+			item.socket.Port,
+			processLabel(item.socket),
+			m.coverageLabel(item.coverage),
+		))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, Space to select, a to allow, l to limit, p to allow matching profile, r to refresh, Esc to go back"
+	return output
+}
+
+func (m ListeningModule) viewPreview() string {
+	lines := []string{"Commands to run:"}
+	if len(m.preview) == 0 {
+		lines = append(lines, "  (nothing to do)")
+	}
+	for _, c := range m.preview {
+		if c.createProfile != nil {
+			lines = append(lines, fmt.Sprintf("  # create profile %s (%s)", c.createProfile.Name, strings.Join(c.createProfile.Ports, ", ")))
+		}
+		lines = append(lines, "  "+c.command)
+	}
+
+	if len(m.skipped) > 0 {
+		lines = append(lines, "", "Skipped:")
+		lo.ForEach(m.skipped, func(s string, _ int) {
+			lines = append(lines, "  "+s)
+		})
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\nEnter to apply, Esc to cancel"
+	return output
+}
+
+func processLabel(s sockets.Socket) string {
+	if s.Process == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%d)", s.Process, s.PID)
+}
+
+func (m ListeningModule) coverageLabel(c sockets.Coverage) string {
+	switch c.Verdict {
+	case sockets.VerdictAllowed, sockets.VerdictDenied:
+		label := fmt.Sprintf("%s by [%d]", c.Verdict, c.Rule.Number)
+		if c.Rule.From.Address != "" {
+			label += " from " + c.Rule.From.Address
+		}
+		return label
+	case sockets.VerdictNotMentioned:
+		if m.defaultIncoming != "" {
+			return fmt.Sprintf("%s (default: %s)", c.Verdict, m.defaultIncoming)
+		}
+	}
+	return string(c.Verdict)
+}
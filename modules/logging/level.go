@@ -0,0 +1,56 @@
+package logging
+
+import "strings"
+
+type Level string
+
+const (
+	LevelOff    Level = "off"
+	LevelLow    Level = "low"
+	LevelMedium Level = "medium"
+	LevelHigh   Level = "high"
+	LevelFull   Level = "full"
+)
+
+var levels = []Level{LevelOff, LevelLow, LevelMedium, LevelHigh, LevelFull}
+
+func (l Level) Description() string {
+	switch l {
+	case LevelOff:
+		return "No logging"
+	case LevelLow:
+		return "Blocked packets not matching the default policy (rate limited), and packets matching logged rules"
+	case LevelMedium:
+		return "Low, plus allowed packets not matching the policy, INVALID packets and all new connections (rate limited)"
+	case LevelHigh:
+		return "Medium without rate limiting, plus all packets with rate limiting"
+	case LevelFull:
+		return "High without rate limiting"
+	}
+	return ""
+}
+
+// ParseLoggingLevel extracts the logging level from `ufw status verbose` output.
+// Example lines: "Logging: on (low)", "Logging: off"
+func ParseLoggingLevel(status string) Level {
+	for _, line := range strings.Split(status, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Logging:") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "Logging:"))
+		if strings.HasPrefix(line, "off") {
+			return LevelOff
+		}
+
+		start := strings.Index(line, "(")
+		end := strings.Index(line, ")")
+		if start != -1 && end > start {
+			return Level(strings.TrimSpace(line[start+1 : end]))
+		}
+		// "on" without an explicit level means the ufw default
+		return LevelLow
+	}
+	return LevelOff
+}
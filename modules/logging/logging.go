@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"fwtui/domain/ufw"
+	"fwtui/utils/focusablelist"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+type LoggingModule struct {
+	current Level
+	levels  *focusablelist.SelectableList[Level]
+}
+
+func Init(current Level) LoggingModule {
+	return LoggingModule{
+		current: current,
+		levels:  focusablelist.FromList(levels).Focus(current),
+	}
+}
+
+// UPDATE
+
+type LoggingUpdatedMsg struct{ Output string }
+type LoggingEscMsg struct{}
+
+func (module LoggingModule) UpdateLoggingModule(msg tea.Msg) (LoggingModule, tea.Cmd) {
+	mod := module
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up", "k":
+			mod.levels.Prev()
+		case "down", "j":
+			mod.levels.Next()
+		case "enter":
+			level := mod.levels.Focused()
+			mod.current = level
+			return mod, teacmd.RunOsCmdAndAfter(func() string {
+				return ufw.SetLogging(string(level))
+			}, func(s string) tea.Msg {
+				return LoggingUpdatedMsg{Output: s}
+			})
+		case "esc":
+			return mod, func() tea.Msg {
+				return LoggingEscMsg{}
+			}
+		}
+	}
+	return mod, nil
+}
+
+// VIEW
+
+func (module LoggingModule) ViewLogging() string {
+	lines := []string{fmt.Sprintf("Logging level (current: %s):", module.current)}
+
+	module.levels.ForEach(func(level Level, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		lines = append(lines, fmt.Sprintf("%s %-8s %s", prefix, level, level.Description()))
+	})
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, Enter to apply, Esc to cancel"
+	return output
+}
@@ -0,0 +1,342 @@
+package nat
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/nat"
+	"fwtui/domain/notification"
+	"fwtui/domain/ufw"
+	"fwtui/modules/createrule"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, hints, help and notifications.
+const listOverhead = 11
+
+type Field string
+
+const (
+	FieldInterface       Field = "Interface"
+	FieldProtocol        Field = "Protocol"
+	FieldPort            Field = "External port"
+	FieldDestination     Field = "Destination IP"
+	FieldDestinationPort Field = "Destination port (Optional)"
+	FieldAllowRoute      Field = "Allow forwarded traffic"
+	FieldSource          Field = "Source network"
+)
+
+type NatModule struct {
+	entries      *focusablelist.SelectableList[nat.Entry]
+	ipForward    bool
+	loadErr      error
+	deleteDialog *confirmation.ConfirmDialog
+	height       int
+
+	form *entryForm // non-nil while adding an entry
+}
+
+type entryForm struct {
+	kind            nat.Kind
+	interface_      *focusablelist.SelectableList[string]
+	protocol        *focusablelist.SelectableList[string]
+	allowRoute      *focusablelist.SelectableList[string]
+	port            string
+	destination     string
+	destinationPort string
+	source          string
+	selectedField   *focusablelist.SelectableList[Field]
+}
+
+func Init(height int) NatModule {
+	return NatModule{height: height}.load()
+}
+
+func (m NatModule) load() NatModule {
+	entries, err := nat.Load()
+	m.loadErr = err
+	m.entries = focusablelist.FromList(entries)
+	m.ipForward, _ = nat.IPForwardEnabled()
+	if m.height > 0 {
+		m.entries.SetHeight(m.height - listOverhead)
+	}
+	return m
+}
+
+func newEntryForm(kind nat.Kind) *entryForm {
+	interfaces, _ := createrule.GetActiveInterfaces()
+	interfaces = lo.Without(interfaces, "")
+
+	fields := []Field{FieldInterface, FieldProtocol, FieldPort, FieldDestination, FieldDestinationPort, FieldAllowRoute}
+	if kind == nat.KindMasquerade {
+		fields = []Field{FieldSource, FieldInterface}
+	}
+	return &entryForm{
+		kind:          kind,
+		interface_:    focusablelist.FromList(interfaces),
+		protocol:      focusablelist.FromList([]string{"tcp", "udp"}),
+		allowRoute:    focusablelist.FromList([]string{"yes", "no"}),
+		selectedField: focusablelist.FromList(fields),
+	}
+}
+
+func (f entryForm) entry() nat.Entry {
+	entry := nat.Entry{Kind: f.kind}
+	if len(f.interface_.GetItems()) > 0 {
+		entry.Interface = f.interface_.Focused()
+	}
+	if f.kind == nat.KindMasquerade {
+		entry.Source = strings.TrimSpace(f.source)
+		return entry
+	}
+	entry.Protocol = f.protocol.Focused()
+	entry.Port = strings.TrimSpace(f.port)
+	entry.Destination = strings.TrimSpace(f.destination)
+	entry.DestinationPort = strings.TrimSpace(f.destinationPort)
+	return entry
+}
+
+// UPDATE
+
+type NatEscMsg struct{}
+
+type natChangedMsg struct{ Output string }
+
+func (mod NatModule) UpdateNatModule(msg tea.Msg) (NatModule, tea.Cmd) {
+	m := mod
+
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.height = msg.Height
+		m.entries.SetHeight(m.height - listOverhead)
+		return m, nil
+	}
+	if msg, ok := msg.(natChangedMsg); ok {
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	}
+
+	if m.form != nil {
+		return m.updateForm(msg)
+	}
+
+	if m.deleteDialog != nil {
+		newDeleteDialog, _, outMsg := m.deleteDialog.UpdateDialog(msg)
+		m.deleteDialog = newDeleteDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.deleteDialog = nil
+			entry := m.entries.Focused()
+			return m, applyCmd(func() error { return nat.Remove(entry) }, func() string {
+				// the route rule would keep allowing traffic no longer forwarded
+				return entity.DeleteRules(entry.RouteRules(entity.ParseRules(ufw.StatusNumbered())))
+			})
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.deleteDialog = nil
+		}
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			m.entries.Prev()
+		case "down", "j":
+			m.entries.Next()
+		case "pgup":
+			m.entries.PageUp()
+		case "pgdown":
+			m.entries.PageDown()
+		case "home":
+			m.entries.FocusFirst()
+		case "end":
+			m.entries.FocusLast()
+		case "a":
+			m.form = newEntryForm(nat.KindForward)
+		case "m":
+			m.form = newEntryForm(nat.KindMasquerade)
+		case "d", "delete":
+			if len(m.entries.GetItems()) > 0 {
+				m.deleteDialog = confirmation.NewConfirmDialog(fmt.Sprintf("Remove %s?", m.entries.Focused()))
+			}
+		case "f":
+			enable := !m.ipForward
+			return m, applyCmd(func() error { return nat.SetIPForward(enable) }, nil)
+		case "r":
+			m = m.load()
+		case "esc":
+			return m, func() tea.Msg {
+				return NatEscMsg{}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m NatModule) updateForm(msg tea.Msg) (NatModule, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	form := m.form
+	key := keyMsg.String()
+
+	var text *string
+	switch form.selectedField.Focused() {
+	case FieldPort:
+		text = &form.port
+	case FieldDestination:
+		text = &form.destination
+	case FieldDestinationPort:
+		text = &form.destinationPort
+	case FieldSource:
+		text = &form.source
+	}
+
+	switch key {
+	case "up":
+		form.selectedField.Prev()
+	case "down":
+		form.selectedField.Next()
+	case "left", "right":
+		var list *focusablelist.SelectableList[string]
+		switch form.selectedField.Focused() {
+		case FieldInterface:
+			list = form.interface_
+		case FieldProtocol:
+			list = form.protocol
+		case FieldAllowRoute:
+			list = form.allowRoute
+		}
+		if list != nil && len(list.GetItems()) > 0 {
+			if key == "left" {
+				list.Prev()
+			} else {
+				list.Next()
+			}
+		}
+	case "backspace":
+		if text != nil {
+			*text = stringsext.TrimLastChar(*text)
+		}
+	case "enter":
+		entry := form.entry()
+		if err := entry.Validate(); err != nil {
+			return m, notification.CreateCmd(err.Error())
+		}
+		m.form = nil
+		var addRoute func() string
+		if entry.Kind == nat.KindForward && form.allowRoute.Focused() == "yes" {
+			addRoute = func() string { return ufw.AddRule(entry.RouteAllowSpec()) }
+		}
+		return m, applyCmd(func() error { return nat.Add(entry) }, addRoute)
+	case "esc":
+		m.form = nil
+	default:
+		if text != nil {
+			*text += teacmd.TypedText(keyMsg)
+		}
+	}
+	return m, nil
+}
+
+// applyCmd changes the ufw files, optionally adds or deletes ufw rules with
+// changeRules, and reloads ufw so the change takes effect.
+func applyCmd(change func() error, changeRules func() string) tea.Cmd {
+	return teacmd.RunOsCmdAndAfter(func() string {
+		if err := change(); err != nil {
+			return "Error: " + err.Error()
+		}
+		var output string
+		if changeRules != nil {
+			output += changeRules()
+		}
+		return output + ufw.Reload()
+	}, func(s string) tea.Msg {
+		return natChangedMsg{Output: s}
+	})
+}
+
+// VIEW
+
+func (m NatModule) ViewNat() string {
+	if m.form != nil {
+		return m.form.view()
+	}
+	if m.deleteDialog != nil {
+		return m.deleteDialog.ViewDialog()
+	}
+
+	lines := []string{
+		fmt.Sprintf("NAT rules in %s: %s", nat.BeforeRulesPath, m.entries.PositionIndicator()),
+		fmt.Sprintf("IPv4 forwarding (%s): %s", nat.SysctlPath, lo.Ternary(m.ipForward, "enabled", "disabled")),
+		"",
+	}
+
+	switch {
+	case m.loadErr != nil:
+		lines = append(lines, "  Error: "+m.loadErr.Error())
+	case len(m.entries.GetItems()) == 0:
+		lines = append(lines, "  No port forwards or masquerading configured.")
+	}
+	m.entries.ForEachVisible(func(entry nat.Entry, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		lines = append(lines, fmt.Sprintf("%s %s", prefix, entry))
+	})
+
+	if len(m.entries.GetItems()) > 0 {
+		lines = append(lines, "", "  "+m.entries.Focused().Line())
+	}
+	if !m.ipForward && len(m.entries.GetItems()) > 0 {
+		lines = append(lines, "", "Warning: NAT rules have no effect while IPv4 forwarding is disabled (press f).")
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, a add port forward, m add masquerade, d remove, f toggle forwarding, r refresh, Esc to go back"
+	return output
+}
+
+func (f entryForm) view() string {
+	title := lo.Ternary(f.kind == nat.KindMasquerade, "Add masquerade (POSTROUTING):", "Add port forward (PREROUTING DNAT):")
+	lines := []string{title, ""}
+
+	for _, field := range f.selectedField.GetItems() {
+		var value string
+		switch field {
+		case FieldInterface:
+			if len(f.interface_.GetItems()) > 0 {
+				value = f.interface_.Focused()
+			}
+		case FieldProtocol:
+			value = f.protocol.Focused()
+		case FieldAllowRoute:
+			value = f.allowRoute.Focused()
+		case FieldPort:
+			value = f.port
+		case FieldDestination:
+			value = f.destination
+		case FieldDestinationPort:
+			value = f.destinationPort
+		case FieldSource:
+			value = f.source
+		}
+		prefix := lo.Ternary(f.selectedField.Focused() == field, "> ", "  ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, field, value))
+	}
+
+	if entry := f.entry(); entry.Validate() == nil {
+		lines = append(lines, "", "  "+entry.Line())
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, ←→ to change selection, type to edit, Enter to add and reload ufw, Esc to cancel"
+	return output
+}
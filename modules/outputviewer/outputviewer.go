@@ -0,0 +1,357 @@
+package outputviewer
+
+import (
+	"fmt"
+	"fwtui/domain/notification"
+	"fwtui/utils/set"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+const chainPrefix = "Chain "
+
+// linesAroundBody is the number of terminal lines used by the title and the help footer.
+const linesAroundBody = 6
+
+type OutputViewer struct {
+	title        string
+	lines        []string
+	pagerCommand string // shell command used to page the output outside of fwtui
+
+	folded set.Set[int] // line indexes of folded chain headers
+	cursor int          // index into visibleLines()
+	offset int
+	height int
+
+	searching bool
+	query     string
+
+	saving   bool
+	savePath string
+}
+
+func New(title, content, pagerCommand string, height int) OutputViewer {
+	return OutputViewer{
+		title:        title,
+		lines:        strings.Split(strings.TrimRight(content, "\n"), "\n"),
+		pagerCommand: pagerCommand,
+		folded:       set.NewSet[int](),
+		height:       height,
+		savePath:     defaultSavePath(title),
+	}
+}
+
+func defaultSavePath(title string) string {
+	name := strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	return fmt.Sprintf("fwtui-%s-%s.txt", name, time.Now().Format("2006-01-02_15-04-05"))
+}
+
+// UPDATE
+
+type OutputViewerEscMsg struct{}
+
+func (v OutputViewer) UpdateOutputViewer(msg tea.Msg) (OutputViewer, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.height = msg.Height
+		v = v.scrollToCursor()
+	case tea.KeyMsg:
+		key := msg.String()
+		switch {
+		case v.searching:
+			return v.updateSearch(msg)
+		case v.saving:
+			return v.updateSave(msg)
+		}
+
+		switch key {
+		case "up", "k":
+			v.cursor--
+		case "down", "j":
+			v.cursor++
+		case "pgup":
+			v.cursor -= v.bodyHeight()
+		case "pgdown":
+			v.cursor += v.bodyHeight()
+		case "home", "g":
+			v.cursor = 0
+		case "end", "G":
+			v.cursor = len(v.visibleLines()) - 1
+		case "enter", " ":
+			v = v.toggleFold()
+		case "z":
+			v = v.toggleAllFolds()
+		case "/":
+			v.searching = true
+			v.query = ""
+		case "n":
+			v = v.jumpToMatch(1)
+		case "N":
+			v = v.jumpToMatch(-1)
+		case "w":
+			v.saving = true
+		case "o":
+			return v, v.openInPager()
+		case "esc", "q":
+			return v, func() tea.Msg {
+				return OutputViewerEscMsg{}
+			}
+		}
+		v = v.scrollToCursor()
+	}
+	return v, nil
+}
+
+func (v OutputViewer) updateSearch(msg tea.KeyMsg) (OutputViewer, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		v.searching = false
+		v = v.jumpToMatch(0).scrollToCursor()
+	case "esc":
+		v.searching = false
+		v.query = ""
+	case "backspace":
+		v.query = stringsext.TrimLastChar(v.query)
+	default:
+		v.query += teacmd.TypedText(msg)
+	}
+	return v, nil
+}
+
+func (v OutputViewer) updateSave(msg tea.KeyMsg) (OutputViewer, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		v.saving = false
+		err := os.WriteFile(v.savePath, []byte(strings.Join(v.lines, "\n")+"\n"), 0644)
+		if err != nil {
+			return v, notification.CreateCmd(fmt.Sprintf("Error saving output: %s", err))
+		}
+		return v, notification.CreateCmd(fmt.Sprintf("Output saved to %s", v.savePath))
+	case "esc":
+		v.saving = false
+	case "backspace":
+		v.savePath = stringsext.TrimLastChar(v.savePath)
+	default:
+		v.savePath += teacmd.TypedText(msg)
+	}
+	return v, nil
+}
+
+// openInPager hands the terminal over to less. It is a fallback for output
+// that does not render well inside fwtui.
+func (v OutputViewer) openInPager() tea.Cmd {
+	cmd := exec.Command("bash", "-c", v.pagerCommand+" | less")
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return notification.NotificationReceivedMsg{Text: fmt.Sprintf("Error running pager: %s", err)}
+		}
+		return nil
+	})
+}
+
+func (v OutputViewer) bodyHeight() int {
+	if v.height <= linesAroundBody {
+		return len(v.lines)
+	}
+	return v.height - linesAroundBody
+}
+
+func (v OutputViewer) scrollToCursor() OutputViewer {
+	visible := len(v.visibleLines())
+	v.cursor = max(0, min(v.cursor, visible-1))
+
+	body := v.bodyHeight()
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+body {
+		v.offset = v.cursor - body + 1
+	}
+	v.offset = max(0, min(v.offset, visible-body))
+	return v
+}
+
+// chainEnd returns the index after the last line of the chain starting at
+// header; chains in `ufw show raw` are separated by blank lines.
+func (v OutputViewer) chainEnd(header int) int {
+	i := header + 1
+	for i < len(v.lines) && strings.TrimSpace(v.lines[i]) != "" && !strings.HasPrefix(v.lines[i], chainPrefix) {
+		i++
+	}
+	return i
+}
+
+func (v OutputViewer) isChainHeader(i int) bool {
+	return strings.HasPrefix(v.lines[i], chainPrefix)
+}
+
+// visibleLines returns the indexes of lines not hidden by a folded chain.
+func (v OutputViewer) visibleLines() []int {
+	var visible []int
+	for i := 0; i < len(v.lines); i++ {
+		visible = append(visible, i)
+		if v.isChainHeader(i) && v.folded.Has(i) {
+			i = v.chainEnd(i) - 1
+		}
+	}
+	return visible
+}
+
+func (v OutputViewer) toggleFold() OutputViewer {
+	visible := v.visibleLines()
+	if len(visible) == 0 {
+		return v
+	}
+	line := visible[v.cursor]
+	// folding from inside a chain folds the chain the cursor is in
+	for line > 0 && !v.isChainHeader(line) && strings.TrimSpace(v.lines[line]) != "" {
+		line--
+	}
+	if !v.isChainHeader(line) {
+		return v
+	}
+
+	v.folded.Toggle(line)
+	v.cursor = lo.IndexOf(v.visibleLines(), line)
+	return v
+}
+
+func (v OutputViewer) toggleAllFolds() OutputViewer {
+	visible := v.visibleLines()
+	var current int
+	if len(visible) > 0 {
+		current = visible[v.cursor]
+	}
+
+	if v.folded.IsEmpty() {
+		for i := range v.lines {
+			if v.isChainHeader(i) {
+				v.folded.Add(i)
+			}
+		}
+	} else {
+		v.folded = set.NewSet[int]()
+	}
+
+	v.cursor = v.visibleIndexOf(current)
+	return v
+}
+
+// visibleIndexOf returns the position of line in visibleLines, falling back
+// to its folded chain header when the line itself is hidden.
+func (v OutputViewer) visibleIndexOf(line int) int {
+	visible := v.visibleLines()
+	for i := len(visible) - 1; i >= 0; i-- {
+		if visible[i] <= line {
+			return i
+		}
+	}
+	return 0
+}
+
+// jumpToMatch moves the cursor to the next (direction 1), previous (-1) or
+// first-from-cursor (0) line containing the search query, unfolding its chain.
+func (v OutputViewer) jumpToMatch(direction int) OutputViewer {
+	if v.query == "" {
+		return v
+	}
+	matches := v.matches()
+	if len(matches) == 0 {
+		return v
+	}
+
+	visible := v.visibleLines()
+	current := 0
+	if len(visible) > 0 {
+		current = visible[v.cursor]
+	}
+
+	target := -1
+	switch direction {
+	case -1:
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < current {
+				target = matches[i]
+				break
+			}
+		}
+		if target == -1 {
+			target = matches[len(matches)-1]
+		}
+	default:
+		for _, match := range matches {
+			if match > current || (direction == 0 && match == current) {
+				target = match
+				break
+			}
+		}
+		if target == -1 {
+			target = matches[0]
+		}
+	}
+
+	for header := range v.folded {
+		if target > header && target < v.chainEnd(header) {
+			v.folded.Remove(header)
+		}
+	}
+	v.cursor = lo.IndexOf(v.visibleLines(), target)
+	return v
+}
+
+func (v OutputViewer) matches() []int {
+	query := strings.ToLower(v.query)
+	var matches []int
+	for i, line := range v.lines {
+		if strings.Contains(strings.ToLower(line), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// VIEW
+
+func (v OutputViewer) ViewOutputViewer() string {
+	visible := v.visibleLines()
+	body := v.bodyHeight()
+	end := min(v.offset+body, len(visible))
+
+	lines := []string{fmt.Sprintf("%s (lines %d-%d of %d)", v.title, v.offset+1, end, len(visible))}
+	for i := v.offset; i < end; i++ {
+		line := visible[i]
+		prefix := lo.Ternary(i == v.cursor, ">", " ")
+		text := v.lines[line]
+		if v.isChainHeader(line) && v.folded.Has(line) {
+			text += fmt.Sprintf(" [+%d lines]", v.chainEnd(line)-line-1)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", prefix, text))
+	}
+
+	output := strings.Join(lines, "\n")
+	switch {
+	case v.searching:
+		output += "\n\nSearch: " + v.query
+		output += "\nType to search, Enter to find, Esc to cancel"
+	case v.saving:
+		output += "\n\nSave to: " + v.savePath
+		output += "\nType to edit path, Enter to save, Esc to cancel"
+	default:
+		if v.query != "" {
+			output += fmt.Sprintf("\n\nSearch: %s (%d matches)", v.query, len(v.matches()))
+		} else {
+			output += "\n"
+		}
+		output += "\n↑↓ PgUp/PgDn Home/End to scroll, Enter to fold chain, z to fold all, / to search, n/N next/prev match, w to save to file, o to open in less, Esc to go back"
+	}
+	return output
+}
@@ -6,6 +6,7 @@ import (
 	"fwtui/domain/notification"
 	"fwtui/utils/focusablelist"
 	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
 	"strconv"
 	"strings"
 
@@ -19,17 +20,24 @@ import (
 type ProfileField string
 
 const (
-	ProfileFormName  ProfileField = "name"
-	ProfileFormTitle ProfileField = "Title"
-	ProfileFormPorts ProfileField = "Ports"
+	ProfileFormName        ProfileField = "name"
+	ProfileFormTitle       ProfileField = "Title"
+	ProfileFormDescription ProfileField = "Description"
+	ProfileFormPorts       ProfileField = "Ports"
 )
 
 type ProfileForm struct {
-	name  string
-	title string
-	ports string
+	name        string
+	title       string
+	description string
+	ports       string
 
 	selectedField *focusablelist.SelectableList[ProfileField]
+
+	editing bool          // edits an installed profile, whose name is fixed
+	file    string        // file defining the edited profile
+	pkg     string        // package owning file
+	usedBy  []entity.Rule // rules referring to the edited profile
 }
 
 func NewProfileForm() ProfileForm {
@@ -37,14 +45,36 @@ func NewProfileForm() ProfileForm {
 		selectedField: focusablelist.FromList([]ProfileField{
 			ProfileFormName,
 			ProfileFormTitle,
+			ProfileFormDescription,
+			ProfileFormPorts,
+		}),
+	}
+}
+
+// NewEditProfileForm opens an installed profile for editing. usedBy are the
+// rules referring to it, shown because they change along with the profile.
+func NewEditProfileForm(p entity.UFWProfile, usedBy []entity.Rule) ProfileForm {
+	return ProfileForm{
+		name:        p.Name,
+		title:       p.Title,
+		description: p.Description,
+		ports:       strings.Join(p.Ports, "|"),
+		selectedField: focusablelist.FromList([]ProfileField{
+			ProfileFormTitle,
+			ProfileFormDescription,
 			ProfileFormPorts,
 		}),
+		editing: true,
+		file:    p.File,
+		pkg:     p.Package,
+		usedBy:  usedBy,
 	}
 }
 
 // UPDATE
 
 type CreateProfileCreatedMsg struct{}
+type EditProfileSavedMsg struct{}
 type CreateProfileEscMsg struct{}
 
 func (f ProfileForm) UpdateProfileForm(msg tea.Msg) (ProfileForm, tea.Cmd) {
@@ -62,6 +92,8 @@ func (f ProfileForm) UpdateProfileForm(msg tea.Msg) (ProfileForm, tea.Cmd) {
 				f.name = stringsext.TrimLastChar(f.name)
 			case ProfileFormTitle:
 				f.title = stringsext.TrimLastChar(f.title)
+			case ProfileFormDescription:
+				f.description = stringsext.TrimLastChar(f.description)
 			case ProfileFormPorts:
 				f.ports = stringsext.TrimLastChar(f.ports)
 			}
@@ -71,6 +103,17 @@ func (f ProfileForm) UpdateProfileForm(msg tea.Msg) (ProfileForm, tea.Cmd) {
 				return f, notification.CreateCmd(res.Err().Error())
 			}
 
+			if f.editing {
+				// package-owned profiles were confirmed before opening the form
+				updateRes := entity.UpdateProfile(res.Value(), true)
+				if updateRes.IsErr() {
+					return f, notification.CreateCmd(updateRes.Err().Error())
+				}
+				return f, tea.Batch(notification.CreateCmd(updateRes.Value()), func() tea.Msg {
+					return EditProfileSavedMsg{}
+				})
+			}
+
 			createProfileRes := entity.CreateProfile(res.Value())
 			if createProfileRes.IsErr() {
 				return f, notification.CreateCmd(createProfileRes.Err().Error())
@@ -86,12 +129,13 @@ func (f ProfileForm) UpdateProfileForm(msg tea.Msg) (ProfileForm, tea.Cmd) {
 		default:
 			switch f.selectedField.Focused() {
 			case ProfileFormName:
-				f.name += key
+				f.name += teacmd.TypedText(msg)
 			case ProfileFormTitle:
-				f.title += key
-
+				f.title += teacmd.TypedText(msg)
+			case ProfileFormDescription:
+				f.description += teacmd.TypedText(msg)
 			case ProfileFormPorts:
-				f.ports += key
+				f.ports += teacmd.TypedText(msg)
 			}
 		}
 	}
@@ -102,6 +146,13 @@ func (f ProfileForm) UpdateProfileForm(msg tea.Msg) (ProfileForm, tea.Cmd) {
 
 func (f ProfileForm) ViewCreateProfile() string {
 	var lines []string
+	if f.editing {
+		lines = append(lines, fmt.Sprintf("Edit profile %s (%s):", f.name, f.file))
+		if f.pkg != "" {
+			lines = append(lines, fmt.Sprintf("Shipped by package %s, upgrades may overwrite your changes.", f.pkg))
+		}
+		lines = append(lines, "")
+	}
 
 	for _, field := range f.selectedField.GetItems() {
 		var value string
@@ -113,6 +164,9 @@ func (f ProfileForm) ViewCreateProfile() string {
 		case ProfileFormTitle:
 			value = f.title
 			label = "Title"
+		case ProfileFormDescription:
+			value = f.description
+			label = "Description"
 		case ProfileFormPorts:
 			value = f.ports
 			label = "Ports"
@@ -125,6 +179,18 @@ func (f ProfileForm) ViewCreateProfile() string {
 		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, label, value))
 	}
 
+	if f.editing {
+		lines = append(lines, "")
+		if len(f.usedBy) == 0 {
+			lines = append(lines, "No rules use this profile.")
+		} else {
+			lines = append(lines, fmt.Sprintf("Used by %d rule(s), they follow the new ports after saving:", len(f.usedBy)))
+			for _, rule := range f.usedBy {
+				lines = append(lines, "  "+rule.Line)
+			}
+		}
+	}
+
 	return strings.Join(lines, "\n") + "\n\n↑↓ to navigate, type to edit, Enter to submit, Esc to cancel"
 }
 
@@ -134,6 +200,9 @@ func (f ProfileForm) BuildUfwProfile() result.Result[entity.UFWProfile] {
 	if strings.TrimSpace(f.name) == "" {
 		return result.Err[entity.UFWProfile](fmt.Errorf("name cannot be empty"))
 	}
+	if f.editing && (strings.TrimSpace(f.title) == "" || strings.TrimSpace(f.description) == "") {
+		return result.Err[entity.UFWProfile](fmt.Errorf("ufw requires a title and a description"))
+	}
 
 	err := validatePorts(f.ports)
 	if err != nil {
@@ -141,9 +210,10 @@ func (f ProfileForm) BuildUfwProfile() result.Result[entity.UFWProfile] {
 	}
 
 	return result.Ok(entity.UFWProfile{
-		Name:  strings.TrimSpace(f.name),
-		Title: strings.TrimSpace(f.title),
-		Ports: strings.Split(strings.TrimSpace(f.ports), "|"),
+		Name:        strings.TrimSpace(f.name),
+		Title:       strings.TrimSpace(f.title),
+		Description: strings.TrimSpace(f.description),
+		Ports:       strings.Split(strings.TrimSpace(f.ports), "|"),
 	})
 }
 
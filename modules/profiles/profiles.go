@@ -2,14 +2,19 @@ package profiles
 
 import (
 	"fmt"
+	"fwtui/domain/bundle"
+	"fwtui/domain/catalog"
 	"fwtui/domain/entity"
 	"fwtui/domain/ufw"
 	"fwtui/modules/profiles/createprofile"
 	"fwtui/modules/shared/confirmation"
 	"fwtui/utils/focusablelist"
 	"fwtui/utils/multiselect"
+	"fwtui/utils/query"
+	stringsext "fwtui/utils/strings"
 	"fwtui/utils/teacmd"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/samber/lo"
@@ -20,20 +25,48 @@ import (
 const menuListProfiles = "INSTALLED_PROFILES"
 const menuCreateFromList = "CREATE_PROFILE_FROM_LIST"
 const menuCreateProfile = "CREATE_PROFILE"
+const menuImportBundle = "IMPORT_BUNDLE"
+
+type Field string
+
+const (
+	FieldBundlePath Field = "Bundle file"
+	FieldConflict   Field = "If a profile exists"
+)
 
 type ProfilesModule struct {
 	view              viewState
 	menu              *focusablelist.SelectableList[string]
 	installedProfiles multiselect.MultiSelectableList[entity.UFWProfile]
 	profilesToInstall multiselect.MultiSelectableList[entity.UFWProfile]
+	filter            query.Input
 
+	rules               []entity.Rule // active rules, to tell which profiles are in use
+	usage               *focusablelist.SelectableList[entity.Rule]
 	deleteDialog        *confirmation.ConfirmDialog
+	deleteRules         []entity.Rule               // rules referring to the profiles being deleted, as shown in the dialog
+	editDialog          *confirmation.ConfirmDialog // confirms editing a package-owned profile
 	createProfileModule createprofile.ProfileForm
+	catalogErrs         []error // catalog files that could not be read
+	importForm          importForm
+	height              int
+}
+
+type importForm struct {
+	path          string
+	conflict      *focusablelist.SelectableList[bundle.Conflict]
+	selectedField *focusablelist.SelectableList[Field]
 }
 
+// listOverhead is the number of lines around a list used by titles, help and notifications.
+const listOverhead = 8
+
+// detailsHeight is the number of lines taken by the detail pane below the installed profiles.
+const detailsHeight = 7
+
 func Init() (ProfilesModule, tea.Cmd) {
 	model := ProfilesModule{
-		menu: focusablelist.FromList([]string{menuListProfiles, menuCreateFromList, menuCreateProfile}),
+		menu: focusablelist.FromList([]string{menuListProfiles, menuCreateFromList, menuCreateProfile, menuImportBundle}),
 		view: viewStateHome,
 	}
 	model = model.reloadInstalledProfiles()
@@ -54,11 +87,29 @@ type profilesCreatedMsg struct {
 	Output string
 }
 
+type profilesExportedMsg struct {
+	Output string
+}
+
+type profilesImportedMsg struct {
+	Output string
+}
+
 type ProfilesEscMsg struct{}
 
 func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea.Cmd) {
 	m := mod
 
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.height = msg.Height
+		m.installedProfiles.SetHeight(m.height - listOverhead - detailsHeight)
+		m.profilesToInstall.SetHeight(m.toInstallHeight())
+		if m.usage != nil {
+			m.usage.SetHeight(m.height - listOverhead)
+		}
+		return m, nil
+	}
+
 	switch true {
 	case m.view.isViewHome():
 		switch msg := msg.(type) {
@@ -77,15 +128,26 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 				switch m.menu.Focused() {
 				case menuListProfiles:
 					m.view = viewStateProfilesList
+					m.rules = entity.ParseRules(ufw.StatusNumbered())
+					m.filter = query.Input{}
+					m.installedProfiles.ClearFilter()
 					m.installedProfiles.ClearSelection()
 					m.installedProfiles.FocusFirst()
 				case menuCreateFromList:
 					m.view = viewStateCreateProfileFromList
+					m.filter = query.Input{}
+					m.profilesToInstall.ClearFilter()
 					m.profilesToInstall.ClearSelection()
 					m.profilesToInstall.FocusFirst()
 				case menuCreateProfile:
 					m.view = viewStateCreateProfile
 					m.createProfileModule = createprofile.NewProfileForm()
+				case menuImportBundle:
+					m.view = viewStateImportBundle
+					m.importForm = importForm{
+						conflict:      focusablelist.FromList([]bundle.Conflict{bundle.ConflictSkip, bundle.ConflictOverwrite, bundle.ConflictRename}),
+						selectedField: focusablelist.FromList([]Field{FieldBundlePath, FieldConflict}),
+					}
 				}
 			}
 		}
@@ -96,17 +158,24 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 			switch outMsg {
 			case confirmation.ConfirmationDialogYes:
 				m.deleteDialog = nil
+				// the dialog named the packages owning any of the profiles and the rules using them
+				confirmedRules := len(m.deleteRules) > 0
+				targets := m.deleteTargets()
 				return m, teacmd.RunOsCmdAndAfter(func() string {
-					if m.installedProfiles.NoneSelected() {
-						return entity.DeleteProfile(m.installedProfiles.FocusedItem())
-					} else {
-						var output string
-						lo.ForEach(m.installedProfiles.GetSelectedItems(), func(profile entity.UFWProfile, _ int) {
-							output += "\n" + entity.DeleteProfile(profile)
-						})
-						return output
+					// rule numbers shift as rules are added or lifted, so the
+					// rules are looked up again right before deleting them
+					rules := rulesUsingProfiles(entity.ParseRules(ufw.StatusNumbered()), targets)
+					var output string
+					if len(rules) > 0 {
+						if !confirmedRules {
+							return fmt.Sprintf("Error: %d rule(s) started using the profile, nothing deleted", len(rules))
+						}
+						output = entity.DeleteRules(rules) + "\n"
 					}
-
+					lo.ForEach(targets, func(profile entity.UFWProfile, _ int) {
+						output += "\n" + entity.DeleteProfile(profile, true)
+					})
+					return output
 				}, func(output string) tea.Msg {
 					return profilesDeletedMsg{Output: output}
 				},
@@ -121,8 +190,22 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 			return m, nil
 		}
 
+		if m.editDialog != nil {
+			newEditDialog, _, outMsg := m.editDialog.UpdateDialog(msg)
+			m.editDialog = newEditDialog
+			switch outMsg {
+			case confirmation.ConfirmationDialogYes:
+				m.editDialog = nil
+				m = m.openEditForm()
+			case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+				m.editDialog = nil
+			}
+			return m, nil
+		}
+
 		switch msg := msg.(type) {
 		case profilesAppliedMsg:
+			m.rules = entity.ParseRules(ufw.StatusNumbered())
 			m.installedProfiles.ClearSelection()
 			m.installedProfiles.FocusFirst()
 			return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
@@ -132,25 +215,89 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 			m = m.reloadProfilesToInstall()
 			m.installedProfiles.ClearSelection()
 			return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+
+		case profilesExportedMsg:
+			m.installedProfiles.ClearSelection()
+			return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
 		case tea.KeyMsg:
 			key := msg.String()
+			if handled, changed := m.filter.HandleKey(msg); handled {
+				if changed {
+					applyFilter(&m.installedProfiles, m.filter)
+				}
+				return m, nil
+			}
+
 			switch key {
 			case "up", "k":
 				m.installedProfiles.Prev()
 			case "down", "j":
 				m.installedProfiles.Next()
+			case "pgup":
+				m.installedProfiles.PageUp()
+			case "pgdown":
+				m.installedProfiles.PageDown()
+			case "home":
+				m.installedProfiles.FocusFirst()
+			case "end":
+				m.installedProfiles.FocusLast()
 			case "delete", "d":
-				if m.installedProfiles.NoneSelected() {
-					m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete this profile?")
-				} else {
-					m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete selected profiles?")
+				if m.installedProfiles.IsEmpty() && m.installedProfiles.NoneSelected() {
+					return m, nil
+				}
+				question := "Are you sure you want to delete this profile?"
+				if !m.installedProfiles.NoneSelected() {
+					question = "Are you sure you want to delete selected profiles?"
+				}
+				targets := m.deleteTargets()
+				m.rules = entity.ParseRules(ufw.StatusNumbered())
+				m.deleteRules = rulesUsingProfiles(m.rules, targets)
+				if len(m.deleteRules) > 0 {
+					question = usageWarning(m.deleteRules)
+				}
+				m.deleteDialog = confirmation.NewConfirmDialog(question + packageWarning(targets))
+			case "u":
+				if m.installedProfiles.IsEmpty() {
+					return m, nil
 				}
+				m.rules = entity.ParseRules(ufw.StatusNumbered())
+				m.usage = focusablelist.FromList(entity.RulesUsingProfile(m.rules, m.installedProfiles.FocusedItem().Name))
+				if m.height > 0 {
+					m.usage.SetHeight(m.height - listOverhead)
+				}
+				m.view = viewStateProfileUsage
 			case "esc":
 				m.view = viewStateHome
 				m.menu.FocusFirst()
 			case " ":
 				m.installedProfiles.Toggle()
+			case "e":
+				if m.installedProfiles.IsEmpty() {
+					return m, nil
+				}
+				profile := m.installedProfiles.FocusedItem()
+				if profile.Package != "" {
+					m.editDialog = confirmation.NewConfirmDialog(fmt.Sprintf("Edit profile %s anyway?%s", profile.Name, packageWarning([]entity.UFWProfile{profile})))
+					return m, nil
+				}
+				m = m.openEditForm()
+			case "x":
+				if m.installedProfiles.IsEmpty() && m.installedProfiles.NoneSelected() {
+					return m, nil
+				}
+				profiles := []entity.UFWProfile{m.installedProfiles.FocusedItem()}
+				if !m.installedProfiles.NoneSelected() {
+					profiles = m.installedProfiles.GetSelectedItems()
+				}
+				return m, teacmd.RunOsCmdAndAfter(func() string {
+					return exportProfiles(profiles)
+				}, func(s string) tea.Msg {
+					return profilesExportedMsg{Output: s}
+				})
 			case "enter":
+				if m.installedProfiles.IsEmpty() && m.installedProfiles.NoneSelected() {
+					return m, nil
+				}
 				return m, teacmd.RunOsCmdAndAfter(func() string {
 					if m.installedProfiles.NoneSelected() {
 						profile := m.installedProfiles.FocusedItem()
@@ -178,16 +325,34 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 
 		case tea.KeyMsg:
 			key := msg.String()
+			if handled, changed := m.filter.HandleKey(msg); handled {
+				if changed {
+					applyFilter(&m.profilesToInstall, m.filter)
+				}
+				return m, nil
+			}
+
 			switch key {
 			case "up", "k":
 				m.profilesToInstall.Prev()
 			case "down", "j":
 				m.profilesToInstall.Next()
+			case "pgup":
+				m.profilesToInstall.PageUp()
+			case "pgdown":
+				m.profilesToInstall.PageDown()
+			case "home":
+				m.profilesToInstall.FocusFirst()
+			case "end":
+				m.profilesToInstall.FocusLast()
 			case "esc":
 				m.view = viewStateHome
 			case " ":
 				m.profilesToInstall.Toggle()
 			case "enter":
+				if m.profilesToInstall.IsEmpty() && m.profilesToInstall.NoneSelected() {
+					return m, nil
+				}
 				return m, teacmd.RunOsCmdAndAfter(func() string {
 					if m.profilesToInstall.NoneSelected() {
 						res := entity.CreateProfile(m.profilesToInstall.FocusedItem())
@@ -227,22 +392,200 @@ func (mod ProfilesModule) UpdateProfilesModule(msg tea.Msg) (ProfilesModule, tea
 		newForm, cmd := m.createProfileModule.UpdateProfileForm(msg)
 		m.createProfileModule = newForm
 		return m, cmd
+	case m.view.isViewEdit():
+		switch msg.(type) {
+		case createprofile.EditProfileSavedMsg:
+			edited := m.installedProfiles.FocusedItem().Name
+			m.view = viewStateProfilesList
+			m = m.reloadInstalledProfiles()
+			_, index, _ := lo.FindIndexOf(m.installedProfiles.Items, func(p entity.UFWProfile) bool { return p.Name == edited })
+			m.installedProfiles.FocusIndex(index)
+			return m, nil
+		case createprofile.CreateProfileEscMsg:
+			m.view = viewStateProfilesList
+			return m, nil
+		}
+
+		newForm, cmd := m.createProfileModule.UpdateProfileForm(msg)
+		m.createProfileModule = newForm
+		return m, cmd
+	case m.view.isViewUsage():
+		keyMsg, ok := msg.(tea.KeyMsg)
+		if !ok {
+			return m, nil
+		}
+		switch keyMsg.String() {
+		case "up", "k":
+			m.usage.Prev()
+		case "down", "j":
+			m.usage.Next()
+		case "pgup":
+			m.usage.PageUp()
+		case "pgdown":
+			m.usage.PageDown()
+		case "esc":
+			m.view = viewStateProfilesList
+		}
+	case m.view.isViewImport():
+		switch msg := msg.(type) {
+		case profilesImportedMsg:
+			m = m.reloadInstalledProfiles()
+			m = m.reloadProfilesToInstall()
+			m.view = viewStateHome
+			return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+		case tea.KeyMsg:
+			return m.updateImportForm(msg)
+		}
 	}
 
 	return m, nil
 }
 
+func (m ProfilesModule) updateImportForm(msg tea.KeyMsg) (ProfilesModule, tea.Cmd) {
+	form := &m.importForm
+	switch msg.String() {
+	case "up":
+		form.selectedField.Prev()
+	case "down":
+		form.selectedField.Next()
+	case "left":
+		if form.selectedField.Focused() == FieldConflict {
+			form.conflict.Prev()
+		}
+	case "right":
+		if form.selectedField.Focused() == FieldConflict {
+			form.conflict.Next()
+		}
+	case "backspace":
+		if form.selectedField.Focused() == FieldBundlePath {
+			form.path = stringsext.TrimLastChar(form.path)
+		}
+	case "enter":
+		path := strings.TrimSpace(form.path)
+		if path == "" {
+			return m, nil
+		}
+		conflict := form.conflict.Focused()
+		return m, teacmd.RunOsCmdAndAfter(func() string {
+			profiles, err := bundle.Read(path)
+			if err != nil {
+				return fmt.Sprintf("Error reading bundle: %s", err)
+			}
+			return bundle.Import(profiles, conflict)
+		}, func(s string) tea.Msg {
+			return profilesImportedMsg{Output: s}
+		})
+	case "esc":
+		m.view = viewStateHome
+	default:
+		if form.selectedField.Focused() == FieldBundlePath {
+			form.path += teacmd.TypedText(msg)
+		}
+	}
+	return m, nil
+}
+
+// exportProfiles writes the profiles as a bundle to the working directory.
+func exportProfiles(profiles []entity.UFWProfile) string {
+	path := fmt.Sprintf("fwtui-profiles-%s.ini", time.Now().Format("2006-01-02_15-04-05"))
+	if err := bundle.Write(path, profiles); err != nil {
+		return fmt.Sprintf("Error exporting profiles: %s", err)
+	}
+	return fmt.Sprintf("%d profiles exported to %s", len(profiles), path)
+}
+
+// deleteTargets returns the selected profiles, or the focused one.
+func (m ProfilesModule) deleteTargets() []entity.UFWProfile {
+	if m.installedProfiles.NoneSelected() {
+		return []entity.UFWProfile{m.installedProfiles.FocusedItem()}
+	}
+	return m.installedProfiles.GetSelectedItems()
+}
+
+func rulesUsingProfiles(rules []entity.Rule, profiles []entity.UFWProfile) []entity.Rule {
+	return lo.UniqBy(lo.FlatMap(profiles, func(profile entity.UFWProfile, _ int) []entity.Rule {
+		return entity.RulesUsingProfile(rules, profile.Name)
+	}), func(rule entity.Rule) int { return rule.Number })
+}
+
+func (m ProfilesModule) openEditForm() ProfilesModule {
+	profile := m.installedProfiles.FocusedItem()
+	usedBy := entity.RulesUsingProfile(entity.ParseRules(ufw.StatusNumbered()), profile.Name)
+	m.createProfileModule = createprofile.NewEditProfileForm(profile, usedBy)
+	m.view = viewStateEditProfile
+	return m
+}
+
+// packageWarning notes the packages shipping any of the profiles, as they may
+// restore or overwrite them on upgrade.
+func packageWarning(profiles []entity.UFWProfile) string {
+	var owned []string
+	for _, profile := range profiles {
+		if profile.Package != "" {
+			owned = append(owned, fmt.Sprintf("%s (package %s)", profile.Name, profile.Package))
+		}
+	}
+	if len(owned) == 0 {
+		return ""
+	}
+	return "\n\nShipped by a package, upgrades may overwrite your changes: " + strings.Join(owned, ", ")
+}
+
+// usageWarning asks to delete the rules along with the profiles, as ufw cannot
+// resolve rules naming a profile that no longer exists.
+func usageWarning(rules []entity.Rule) string {
+	lines := []string{fmt.Sprintf("%d rule(s) still use the profile:", len(rules))}
+	for _, rule := range rules {
+		lines = append(lines, "  "+rule.Line)
+	}
+	lines = append(lines, "", "Also delete the referencing rules? No keeps the profile and the rules.")
+	return strings.Join(lines, "\n")
+}
+
 func (m ProfilesModule) reloadInstalledProfiles() ProfilesModule {
+	m.rules = entity.ParseRules(ufw.StatusNumbered())
 	profiles, _ := entity.LoadInstalledProfiles()
 	m.installedProfiles = multiselect.FromList(profiles)
+	if m.height > 0 {
+		m.installedProfiles.SetHeight(m.height - listOverhead - detailsHeight)
+	}
+	if m.view.isViewList() {
+		applyFilter(&m.installedProfiles, m.filter)
+	}
 	return m
 }
 
 func (m ProfilesModule) reloadProfilesToInstall() ProfilesModule {
-	m.profilesToInstall = multiselect.FromList(entity.InstallableProfiles())
+	profiles, errs := catalog.InstallableProfiles()
+	m.profilesToInstall = multiselect.FromList(profiles)
+	m.catalogErrs = errs
+	if m.height > 0 {
+		m.profilesToInstall.SetHeight(m.toInstallHeight())
+	}
+	if m.view.isViewCreateFromList() {
+		applyFilter(&m.profilesToInstall, m.filter)
+	}
 	return m
 }
 
+// toInstallHeight leaves room for a header per category and the catalog errors.
+func (m ProfilesModule) toInstallHeight() int {
+	categories := lo.Uniq(lo.Map(m.profilesToInstall.Items, func(p entity.UFWProfile, _ int) string { return p.Category }))
+	return m.height - listOverhead - len(categories) - len(m.catalogErrs)
+}
+
+func applyFilter(list *multiselect.MultiSelectableList[entity.UFWProfile], filter query.Input) {
+	if !filter.IsActive() {
+		list.ClearFilter()
+		return
+	}
+
+	q := filter.Query()
+	list.SetFilter(func(profile entity.UFWProfile) bool {
+		return q.Matches(profile.MatchesTerm)
+	})
+}
+
 // VIEW
 
 func (m ProfilesModule) ViewProfiles() string {
@@ -262,6 +605,8 @@ func (m ProfilesModule) ViewProfiles() string {
 				itemName = "Create (from list)"
 			case menuCreateProfile:
 				itemName = "Create"
+			case menuImportBundle:
+				itemName = "Import bundle"
 			}
 			lines = append(lines, fmt.Sprintf("%s %s", prefix, itemName))
 		})
@@ -270,19 +615,42 @@ func (m ProfilesModule) ViewProfiles() string {
 		if m.deleteDialog != nil {
 			return m.deleteDialog.ViewDialog()
 		}
-		lines := []string{"Focus profile:"}
-		m.installedProfiles.ForEach(func(profile entity.UFWProfile, index int, isFocused, isSelected bool) {
+		if m.editDialog != nil {
+			return m.editDialog.ViewDialog()
+		}
+		lines := []string{"Focus profile: " + m.installedProfiles.PositionIndicator()}
+		if filter := m.filter.View(); filter != "" {
+			lines = append(lines, filter)
+		}
+		m.installedProfiles.ForEachVisible(func(profile entity.UFWProfile, index int, isFocused, isSelected bool) {
 			focusedPrefix := lo.Ternary(isFocused, ">", " ")
 			selectedPrefix := lo.Ternary(isSelected, "*", " ")
 			prefix := focusedPrefix + selectedPrefix
-			lines = append(lines, fmt.Sprintf("%s %-20s | %-45s | %-45s", prefix, profile.Name, profile.Title, strings.Join(profile.Ports, ", ")))
+			used := len(entity.RulesUsingProfile(m.rules, profile.Name))
+			lines = append(lines, fmt.Sprintf("%s %-20s | %-45s | %-45s | %s", prefix, profile.Name, profile.Title, strings.Join(profile.Ports, ", "), usageLabel(used)))
 		})
 
+		if !m.installedProfiles.IsEmpty() {
+			profile := m.installedProfiles.FocusedItem()
+			lines = append(lines, "", viewDetails(profile, entity.RulesUsingProfile(m.rules, profile.Name)))
+		}
+
 		output = strings.Join(lines, "\n")
-		output += "\n\n↑↓ to navigate, d to delete, Space to select, Enter to enable profile, Esc to cancel"
+		output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, e to edit, d to delete, u to show rules using it, x to export, Space to select, / to filter (name: title: description: port:), Enter to enable profile, Esc to cancel"
 	case m.view.isViewCreateFromList():
-		lines := []string{"Focus profile to install:"}
-		m.profilesToInstall.ForEach(func(profile entity.UFWProfile, index int, isFocused, isSelected bool) {
+		lines := []string{"Focus profile to install: " + m.profilesToInstall.PositionIndicator()}
+		for _, err := range m.catalogErrs {
+			lines = append(lines, "Skipped catalog file "+err.Error())
+		}
+		if filter := m.filter.View(); filter != "" {
+			lines = append(lines, filter)
+		}
+		category := ""
+		m.profilesToInstall.ForEachVisible(func(profile entity.UFWProfile, index int, isFocused, isSelected bool) {
+			if profile.Category != category {
+				category = profile.Category
+				lines = append(lines, category+":")
+			}
 			focusedPrefix := lo.Ternary(isFocused, ">", " ")
 			selectedPrefix := lo.Ternary(isSelected, "*", " ")
 			prefix := focusedPrefix + selectedPrefix
@@ -290,10 +658,74 @@ func (m ProfilesModule) ViewProfiles() string {
 		})
 
 		output = strings.Join(lines, "\n")
-		output += "\n\n↑↓ to navigate, Space to select, Enter to create profile, Esc to cancel"
-	case m.view.isViewCreate():
+		output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, Space to select, / to filter (name: title: description: category: port:), Enter to create profile, Esc to cancel"
+	case m.view.isViewCreate(), m.view.isViewEdit():
 		output = m.createProfileModule.ViewCreateProfile()
+	case m.view.isViewUsage():
+		profile := m.installedProfiles.FocusedItem()
+		lines := []string{fmt.Sprintf("Rules using profile %s: %s", profile.Name, m.usage.PositionIndicator())}
+		if len(m.usage.GetItems()) == 0 {
+			lines = append(lines, "  No rules use this profile, it can be deleted safely.")
+		}
+		m.usage.ForEachVisible(func(rule entity.Rule, _ int, isSelected bool) {
+			prefix := lo.Ternary(isSelected, ">", " ")
+			lines = append(lines, prefix+" "+rule.Line)
+		})
+		output = strings.Join(lines, "\n")
+		output += "\n\n↑↓ PgUp/PgDn to navigate, Esc to go back"
+	case m.view.isViewImport():
+		output = m.importForm.view()
+	}
+
+	return output
+}
+
+func usageLabel(used int) string {
+	if used == 0 {
+		return "unused"
+	}
+	return fmt.Sprintf("%d rule(s)", used)
+}
+
+func viewDetails(profile entity.UFWProfile, usedBy []entity.Rule) string {
+	file := profile.File
+	if profile.Package != "" {
+		file += " (package " + profile.Package + ")"
+	}
+	lines := []string{
+		"Profile " + profile.Name,
+		fmt.Sprintf("  Title:       %s", profile.Title),
+		fmt.Sprintf("  Description: %s", profile.Description),
+		fmt.Sprintf("  Ports:       %s", strings.Join(profile.Ports, ", ")),
+		fmt.Sprintf("  File:        %s", file),
+		fmt.Sprintf("  Used by:     %s", usageLabel(len(usedBy))),
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f importForm) view() string {
+	lines := []string{"Import profiles exported with x from the profile list:", ""}
+	for _, field := range f.selectedField.GetItems() {
+		var value string
+		switch field {
+		case FieldBundlePath:
+			value = f.path
+		case FieldConflict:
+			value = string(f.conflict.Focused())
+			switch f.conflict.Focused() {
+			case bundle.ConflictSkip:
+				value += " (keep the installed profile)"
+			case bundle.ConflictOverwrite:
+				value += " (replace title, description and ports, package profiles are kept)"
+			case bundle.ConflictRename:
+				value += " (install as name-2, name-3, ...)"
+			}
+		}
+		prefix := lo.Ternary(f.selectedField.Focused() == field, "> ", "  ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, field, value))
 	}
 
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ to navigate, ←→ to change selection, type to edit, Enter to import, Esc to cancel"
 	return output
 }
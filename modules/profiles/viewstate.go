@@ -18,8 +18,23 @@ func (v viewState) isViewCreate() bool {
 	return v == viewStateCreateProfile
 }
 
+func (v viewState) isViewEdit() bool {
+	return v == viewStateEditProfile
+}
+
+func (v viewState) isViewUsage() bool {
+	return v == viewStateProfileUsage
+}
+
+func (v viewState) isViewImport() bool {
+	return v == viewStateImportBundle
+}
+
 const viewStateHome = "home"
 
 const viewStateProfilesList = "profiles_list"
 const viewStateCreateProfileFromList = "create_profile_from_list"
 const viewStateCreateProfile = "create_profile"
+const viewStateEditProfile = "edit_profile"
+const viewStateImportBundle = "import_bundle"
+const viewStateProfileUsage = "profile_usage"
@@ -0,0 +1,179 @@
+package rules
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	stringsext "fwtui/utils/strings"
+	"sort"
+	"strings"
+	"time"
+)
+
+type sortColumn int
+
+const (
+	sortNumber sortColumn = iota
+	sortAction
+	sortDirection
+	sortFrom
+	sortTo
+	sortPort
+	sortInterface
+	sortComment
+	sortVersion
+)
+
+var sortColumnNames = []string{"#", "action", "direction", "from", "to", "port", "interface", "comment", "ip version"}
+
+func (c sortColumn) next() sortColumn {
+	return (c + 1) % sortColumn(len(sortColumnNames))
+}
+
+func (c sortColumn) String() string {
+	return sortColumnNames[c]
+}
+
+func sortRules(rules []entity.Rule, column sortColumn, descending bool) []entity.Rule {
+	sorted := make([]entity.Rule, len(rules))
+	copy(sorted, rules)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if descending {
+			a, b = b, a
+		}
+		if column == sortNumber {
+			return numberKey(a) < numberKey(b)
+		}
+		keyA, keyB := sortKey(a, column), sortKey(b, column)
+		if keyA == keyB {
+			return a.Number < b.Number
+		}
+		return keyA < keyB
+	})
+	return sorted
+}
+
+// numberKey orders disabled rules after the active ones.
+func numberKey(rule entity.Rule) int {
+	if rule.Disabled {
+		return 1<<30 - rule.Number
+	}
+	return rule.Number
+}
+
+func sortKey(rule entity.Rule, column sortColumn) string {
+	switch column {
+	case sortAction:
+		return rule.Action
+	case sortDirection:
+		return rule.Direction
+	case sortFrom:
+		return endpointLabel(rule.From)
+	case sortTo:
+		return endpointLabel(rule.To)
+	case sortPort:
+		return rule.PortLabel()
+	case sortInterface:
+		return interfaceLabel(rule)
+	case sortComment:
+		return strings.ToLower(rule.Comment)
+	case sortVersion:
+		return rule.IPVersion()
+	}
+	return ""
+}
+
+func endpointLabel(e entity.Endpoint) string {
+	if e.Address == "" {
+		return "Anywhere"
+	}
+	return e.Address
+}
+
+func interfaceLabel(rule entity.Rule) string {
+	switch {
+	case rule.To.Interface != "" && rule.From.Interface != "":
+		return rule.To.Interface + ">" + rule.From.Interface
+	case rule.To.Interface != "":
+		return rule.To.Interface
+	}
+	return rule.From.Interface
+}
+
+const rowFormat = "%-5s %-7s %-4s %-20s %-20s %-16s %-8s %-24s %-3s %s"
+
+func tableHeader() string {
+	return fmt.Sprintf("   "+rowFormat, "#", "ACTION", "DIR", "FROM", "TO", "PORT", "ON", "COMMENT", "IP", "HITS")
+}
+
+func tableRow(rule entity.Rule, hits string) string {
+	from := endpointLabel(rule.From)
+	if rule.From.Ports != "" {
+		from += ":" + rule.From.Ports
+	}
+	return fmt.Sprintf(rowFormat,
+		numberLabel(rule),
+		rule.Action,
+		rule.Direction,
+		stringsext.Truncate(from, 20),
+		stringsext.Truncate(endpointLabel(rule.To), 20),
+		stringsext.Truncate(rule.PortLabel(), 16),
+		stringsext.Truncate(interfaceLabel(rule), 8),
+		stringsext.Truncate(commentLabel(rule), 24),
+		rule.IPVersion(),
+		hits,
+	)
+}
+
+func numberLabel(rule entity.Rule) string {
+	if rule.Disabled {
+		return "[off]"
+	}
+	return fmt.Sprintf("[%d]", rule.Number)
+}
+
+// commentLabel shows the remaining lifetime of temporary rules in place of their expiry tag.
+func commentLabel(rule entity.Rule) string {
+	at, ok := rule.ExpiresAt()
+	if !ok {
+		return rule.Comment
+	}
+	return strings.TrimSpace(fmt.Sprintf("(%s) %s", entity.FormatRemaining(at, time.Now()), entity.WithoutExpiry(rule.Comment)))
+}
+
+func formatCount(n uint64) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fG", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatBytes(n uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
@@ -0,0 +1,545 @@
+package rules
+
+import (
+	"fmt"
+	"fwtui/domain/counters"
+	"fwtui/domain/entity"
+	"fwtui/domain/expiry"
+	"fwtui/domain/notification"
+	"fwtui/domain/parked"
+	"fwtui/domain/ufw"
+	"fwtui/modules/createrule"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/multiselect"
+	"fwtui/utils/query"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the table used by titles, help and notifications.
+const listOverhead = 10
+
+// detailsHeight is the number of lines taken by the detail pane.
+const detailsHeight = 12
+
+type RulesModule struct {
+	view         viewState
+	all          []entity.Rule // in ufw order
+	parked       []parked.ParkedRule
+	disabled     []entity.Rule // parked rules, numbered -1, -2, ...
+	rules        multiselect.MultiSelectableList[entity.Rule]
+	filter       query.Input
+	sortBy       sortColumn
+	sortDesc     bool
+	showDetails  bool
+	deleteDialog *confirmation.ConfirmDialog
+	resetDialog  *confirmation.ConfirmDialog
+	ruleForm     createrule.RuleForm
+	height       int
+
+	hits       map[int]counters.Counter // by rule number, nil when counters are unavailable
+	onlyUnused bool
+}
+
+func Init(height int) RulesModule {
+	m := RulesModule{
+		view:   viewStateList,
+		height: height,
+	}
+	return m.Reload()
+}
+
+// Reload re-reads the rules from ufw, keeping sort order, filter and focus.
+func (m RulesModule) Reload() RulesModule {
+	m.all = entity.ParseRules(ufw.StatusNumbered())
+	m.hits, _ = counters.Load(m.all)
+	m.parked, _ = parked.Load()
+	m.disabled = parked.Rules(m.parked)
+	return m.refreshList()
+}
+
+func (m RulesModule) Count() int {
+	return len(m.all)
+}
+
+// FocusRule focuses the rule with the given number, clearing a filter hiding it.
+func (m RulesModule) FocusRule(number int) RulesModule {
+	for i, rule := range m.rules.Items {
+		if rule.Number == number {
+			if m.filter.IsActive() && !m.filter.Query().Matches(rule.MatchesTerm) {
+				m.filter = query.Input{}
+			}
+			if m.onlyUnused && !m.isUnused(rule) {
+				m.onlyUnused = false
+			}
+			m = m.applyFilter()
+			m.rules.FocusIndex(i)
+			break
+		}
+	}
+	return m
+}
+
+// FilterBy replaces the filter with text, e.g. "tag:team:web".
+func (m RulesModule) FilterBy(text string) RulesModule {
+	m.filter = query.Input{Text: text}
+	return m.applyFilter()
+}
+
+// refreshList rebuilds the visible list from m.all, preserving focus and selection by rule number.
+func (m RulesModule) refreshList() RulesModule {
+	focused := -1
+	if !m.rules.IsEmpty() {
+		focused = m.rules.FocusedItem().Number
+	}
+	selected := lo.Map(m.rules.GetSelectedItems(), func(rule entity.Rule, _ int) int {
+		return rule.Number
+	})
+
+	m.rules.SetItems(sortRules(append(slices.Clone(m.all), m.disabled...), m.sortBy, m.sortDesc))
+	if m.height > 0 {
+		m.rules.SetHeight(m.listHeight())
+	}
+	m = m.applyFilter()
+
+	for i, rule := range m.rules.Items {
+		if lo.Contains(selected, rule.Number) {
+			m.rules.Selected.Add(i)
+		}
+		if rule.Number == focused {
+			m.rules.FocusIndex(i)
+		}
+	}
+	return m
+}
+
+func (m RulesModule) applyFilter() RulesModule {
+	if !m.filter.IsActive() && !m.onlyUnused {
+		m.rules.ClearFilter()
+		return m
+	}
+
+	q := m.filter.Query()
+	m.rules.SetFilter(func(rule entity.Rule) bool {
+		if m.onlyUnused && !m.isUnused(rule) {
+			return false
+		}
+		return q.Matches(rule.MatchesTerm)
+	})
+	return m
+}
+
+// isUnused reports whether the rule matched no packet since the counters were reset.
+func (m RulesModule) isUnused(rule entity.Rule) bool {
+	counter, ok := m.hits[rule.Number]
+	return ok && counter.Packets == 0
+}
+
+func (m RulesModule) listHeight() int {
+	if m.height <= 0 {
+		return 0
+	}
+	return m.height - listOverhead - lo.Ternary(m.showDetails, detailsHeight, 0)
+}
+
+// targets returns the selected rules, or the focused one when nothing is selected.
+func (m RulesModule) targets() []entity.Rule {
+	if m.rules.NoneSelected() {
+		if m.rules.IsEmpty() {
+			return nil
+		}
+		return []entity.Rule{m.rules.FocusedItem()}
+	}
+	return m.rules.GetSelectedItems()
+}
+
+// twinOf returns the IPv4/IPv6 counterpart of rule, if ufw created one.
+func (m RulesModule) twinOf(rule entity.Rule) (entity.Rule, bool) {
+	return lo.Find(m.all, func(other entity.Rule) bool {
+		return rule.IsTwinOf(other)
+	})
+}
+
+// UPDATE
+
+type RulesEscMsg struct{}
+
+type rulesChangedMsg struct{ Output string }
+
+func (mod RulesModule) UpdateRulesModule(msg tea.Msg) (RulesModule, tea.Cmd) {
+	m := mod
+
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.height = msg.Height
+		m.rules.SetHeight(m.listHeight())
+		return m, nil
+	}
+
+	switch true {
+	case m.view.isViewForm():
+		switch msg.(type) {
+		case createrule.CreateRuleCreatedMsg:
+			m.view = viewStateList
+			m = m.Reload()
+			return m, nil
+		case createrule.CreateRuleEscMsg:
+			m.view = viewStateList
+			return m, nil
+		}
+
+		newForm, cmd := m.ruleForm.UpdateRuleForm(msg)
+		m.ruleForm = newForm
+		return m, cmd
+
+	case m.view.isViewList():
+		if m.deleteDialog != nil {
+			newDeleteDialog, _, outMsg := m.deleteDialog.UpdateDialog(msg)
+			m.deleteDialog = newDeleteDialog
+			switch outMsg {
+			case confirmation.ConfirmationDialogYes:
+				m.deleteDialog = nil
+				targets := m.targets()
+				return m, teacmd.RunOsCmdAndAfter(func() string {
+					return parked.Delete(targets)
+				}, func(s string) tea.Msg {
+					return rulesChangedMsg{Output: s}
+				})
+			case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+				m.deleteDialog = nil
+			}
+			return m, nil
+		}
+
+		if m.resetDialog != nil {
+			newResetDialog, _, outMsg := m.resetDialog.UpdateDialog(msg)
+			m.resetDialog = newResetDialog
+			switch outMsg {
+			case confirmation.ConfirmationDialogYes:
+				m.resetDialog = nil
+				return m, teacmd.RunOsCmdAndAfter(counters.Reset, func(s string) tea.Msg {
+					return rulesChangedMsg{Output: s}
+				})
+			case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+				m.resetDialog = nil
+			}
+			return m, nil
+		}
+
+		switch msg := msg.(type) {
+		case rulesChangedMsg:
+			m.rules.ClearSelection()
+			m = m.Reload()
+			return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+
+		case tea.KeyMsg:
+			key := msg.String()
+			if handled, changed := m.filter.HandleKey(msg); handled {
+				if changed {
+					m = m.applyFilter()
+				}
+				return m, nil
+			}
+
+			switch key {
+			case "up", "k":
+				m.rules.Prev()
+			case "down", "j":
+				m.rules.Next()
+			case "pgup":
+				m.rules.PageUp()
+			case "pgdown":
+				m.rules.PageDown()
+			case "home":
+				m.rules.FocusFirst()
+			case "end":
+				m.rules.FocusLast()
+			case " ":
+				m.rules.Toggle()
+			case "s":
+				m.sortBy = m.sortBy.next()
+				m = m.refreshList()
+			case "S":
+				m.sortDesc = !m.sortDesc
+				m = m.refreshList()
+			case "tab":
+				m.showDetails = !m.showDetails
+				if m.height > 0 {
+					m.rules.SetHeight(m.listHeight())
+				}
+			case "r":
+				m = m.Reload()
+			case "u":
+				if m.hits == nil {
+					return m, notification.CreateCmd("Hit counters are not available")
+				}
+				m.onlyUnused = !m.onlyUnused
+				m = m.applyFilter()
+			case "o":
+				targets := m.targets()
+				if len(targets) == 0 {
+					return m, nil
+				}
+				return m, teacmd.RunOsCmdAndAfter(func() string {
+					return lo.CoalesceOrEmpty(parked.Toggle(targets), "Rules toggled")
+				}, func(s string) tea.Msg {
+					return rulesChangedMsg{Output: s}
+				})
+			case "x":
+				return m, teacmd.RunOsCmdAndAfter(func() string {
+					_, output := expiry.RemoveExpired(time.Now())
+					return lo.CoalesceOrEmpty(output, "No expired rules")
+				}, func(s string) tea.Msg {
+					return rulesChangedMsg{Output: s}
+				})
+			case "Z":
+				if m.hits == nil {
+					return m, notification.CreateCmd("Hit counters are not available")
+				}
+				m.resetDialog = confirmation.NewConfirmDialog("Reset the hit counters of all rules?")
+			case "n":
+				m.ruleForm = createrule.NewRuleForm()
+				m.view = viewStateForm
+			case "e":
+				return m.editFocused()
+			case "c":
+				return m.duplicateFocused()
+			case "K":
+				return m.moveFocused(-1)
+			case "J":
+				return m.moveFocused(1)
+			case "d", "delete":
+				if len(m.targets()) == 0 {
+					return m, nil
+				}
+				if m.rules.NoneSelected() {
+					m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete this rule?")
+				} else {
+					m.deleteDialog = confirmation.NewConfirmDialog("Are you sure you want to delete selected rules?")
+				}
+			case "esc":
+				return m, func() tea.Msg {
+					return RulesEscMsg{}
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m RulesModule) editFocused() (RulesModule, tea.Cmd) {
+	if m.rules.IsEmpty() {
+		return m, nil
+	}
+	rule := m.rules.FocusedItem()
+	if rule.Disabled {
+		return m, notification.CreateCmd("Enable the rule first")
+	}
+
+	res := createrule.NewRuleFormFromRule(rule)
+	if res.IsErr() {
+		return m, notification.CreateCmd(res.Err().Error())
+	}
+
+	replaces := []int{rule.Number}
+	position := rule.Number
+	if twin, ok := m.twinOf(rule); ok {
+		replaces = append(replaces, twin.Number)
+		position = min(rule.Number, twin.Number)
+	}
+
+	m.ruleForm = res.Value().Replacing(replaces...).InsertAt(position)
+	m.view = viewStateForm
+	return m, nil
+}
+
+func (m RulesModule) duplicateFocused() (RulesModule, tea.Cmd) {
+	if m.rules.IsEmpty() {
+		return m, nil
+	}
+	rule := m.rules.FocusedItem()
+	if rule.Disabled {
+		return m, notification.CreateCmd("Enable the rule first")
+	}
+
+	res := createrule.NewRuleFormFromRule(rule)
+	if res.IsErr() {
+		return m, notification.CreateCmd(res.Err().Error())
+	}
+
+	m.ruleForm = res.Value().InsertAt(rule.Number + 1)
+	m.view = viewStateForm
+	return m, nil
+}
+
+func (m RulesModule) moveFocused(delta int) (RulesModule, tea.Cmd) {
+	if m.rules.IsEmpty() {
+		return m, nil
+	}
+	if m.sortBy != sortNumber || m.sortDesc {
+		return m, notification.CreateCmd("Sort by # to move rules")
+	}
+
+	rule := m.rules.FocusedItem()
+	if rule.Disabled {
+		return m, notification.CreateCmd("Disabled rules cannot be moved")
+	}
+	target := rule.Number + delta
+	if target < 1 || target > len(m.all) {
+		return m, nil
+	}
+
+	return m, teacmd.RunOsCmdAndAfter(func() string {
+		return moveRule(rule, target)
+	}, func(s string) tea.Msg {
+		return rulesChangedMsg{Output: s}
+	})
+}
+
+// moveRule deletes the rule and inserts it again at target. ufw refuses to
+// insert a duplicate, so the rule has to be deleted first; if the insert
+// fails the rule is put back where it was.
+func moveRule(rule entity.Rule, target int) string {
+	output := ufw.DeleteRuleByNumber(rule.Number)
+	if entity.Failed(output) {
+		return output
+	}
+	inserted := entity.InsertOrAppend(target, rule.Spec())
+	output += inserted
+	if entity.Failed(inserted) {
+		restored, ok := entity.RestoreRule(rule)
+		output += restored + lo.Ternary(ok, "Rule not moved\n", "")
+	}
+	return output
+}
+
+// VIEW
+
+func (m RulesModule) ViewRules() string {
+	switch true {
+	case m.view.isViewForm():
+		return m.ruleForm.ViewCreateRule()
+	}
+
+	if m.deleteDialog != nil {
+		return m.deleteDialog.ViewDialog()
+	}
+	if m.resetDialog != nil {
+		return m.resetDialog.ViewDialog()
+	}
+
+	title := fmt.Sprintf("Rules (sorted by %s%s): %s", m.sortBy, lo.Ternary(m.sortDesc, ", descending", ""), m.rules.PositionIndicator())
+	lines := []string{title, m.viewCountersPeriod()}
+	if filter := m.filter.View(); filter != "" {
+		lines = append(lines, filter)
+	}
+	lines = append(lines, tableHeader())
+
+	if len(m.all) == 0 {
+		lines = append(lines, "   (no rules)")
+	}
+	m.rules.ForEachVisible(func(rule entity.Rule, _ int, isFocused, isSelected bool) {
+		focusedPrefix := lo.Ternary(isFocused, ">", " ")
+		selectedPrefix := lo.Ternary(isSelected, "*", " ")
+		row := tableRow(rule, m.hitsLabel(rule))
+		if rule.Disabled {
+			row = stringsext.Faint(row)
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s", focusedPrefix, selectedPrefix, row))
+	})
+
+	if m.showDetails && !m.rules.IsEmpty() {
+		lines = append(lines, "", m.viewDetails(m.rules.FocusedItem()))
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ PgUp/PgDn Home/End to navigate, Space to select, / to filter, s/S to sort, Tab for details, " +
+		"n new, e edit, c duplicate, K/J move up/down, d delete, o enable/disable, u unused only, Z reset counters, x remove expired, r refresh, Esc to go back"
+	return output
+}
+
+func (m RulesModule) viewCountersPeriod() string {
+	if m.hits == nil {
+		return "Hit counters unavailable"
+	}
+	label := "Hit counters since last ufw reload"
+	if since, ok := counters.Since(); ok {
+		label = fmt.Sprintf("Hit counters since %s (%s ago)", since.Format("2006-01-02 15:04"), formatDuration(time.Since(since)))
+	}
+	return label + lo.Ternary(m.onlyUnused, ", showing unused rules only", "")
+}
+
+func (m RulesModule) hitsLabel(rule entity.Rule) string {
+	counter, ok := m.hits[rule.Number]
+	switch {
+	case rule.Disabled:
+		return ""
+	case !ok:
+		return "?"
+	case counter.Packets == 0:
+		return "unused"
+	}
+	return formatCount(counter.Packets)
+}
+
+func (m RulesModule) viewDetails(rule entity.Rule) string {
+	lines := []string{
+		fmt.Sprintf("Rule %s (%s)", numberLabel(rule), rule.IPVersion()),
+		fmt.Sprintf("  Action:    %s %s", rule.Action, rule.Direction),
+		fmt.Sprintf("  From:      %s", endpointDetails(rule.From)),
+		fmt.Sprintf("  To:        %s", endpointDetails(rule.To)),
+		fmt.Sprintf("  Interface: %s", lo.CoalesceOrEmpty(interfaceLabel(rule), "any")),
+		fmt.Sprintf("  Comment:   %s", rule.Comment),
+		fmt.Sprintf("  Expires:   %s", expiryDetails(rule)),
+		fmt.Sprintf("  Hits:      %s", m.hitsDetails(rule)),
+		fmt.Sprintf("  Command:   ufw %s", rule.Spec()),
+		fmt.Sprintf("  Status:    %s", m.statusDetails(rule)),
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m RulesModule) statusDetails(rule entity.Rule) string {
+	p, ok := parked.Find(m.parked, rule)
+	if !ok {
+		return rule.Line
+	}
+	return fmt.Sprintf("disabled since %s, was rule %d", p.ParkedAt.Local().Format("2006-01-02 15:04"), p.Position)
+}
+
+func (m RulesModule) hitsDetails(rule entity.Rule) string {
+	counter, ok := m.hits[rule.Number]
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d packets, %s", counter.Packets, formatBytes(counter.Bytes))
+}
+
+func expiryDetails(rule entity.Rule) string {
+	at, ok := rule.ExpiresAt()
+	if !ok {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s)", at.Local().Format("2006-01-02 15:04"), entity.FormatRemaining(at, time.Now()))
+}
+
+func endpointDetails(e entity.Endpoint) string {
+	label := endpointLabel(e)
+	switch {
+	case e.App != "":
+		label += " app " + e.App
+	case e.Ports != "":
+		label += " port " + e.Ports
+	}
+	if e.Protocol != "" {
+		label += "/" + e.Protocol
+	}
+	return label
+}
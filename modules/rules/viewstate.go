@@ -0,0 +1,14 @@
+package rules
+
+type viewState string
+
+func (v viewState) isViewList() bool {
+	return v == viewStateList
+}
+
+func (v viewState) isViewForm() bool {
+	return v == viewStateForm
+}
+
+const viewStateList = "list"
+const viewStateForm = "form"
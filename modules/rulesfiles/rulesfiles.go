@@ -0,0 +1,268 @@
+package rulesfiles
+
+import (
+	"fmt"
+	"fwtui/domain/rulesfile"
+	"fwtui/domain/ufw"
+	"fwtui/modules/shared/confirmation"
+	"fwtui/utils/focusablelist"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+// listOverhead is the number of lines around the list used by titles, help and notifications.
+const listOverhead = 9
+
+type rowKind int
+
+const (
+	rowTable rowKind = iota
+	rowChain
+	rowRule
+)
+
+// row is one line of the tree: a table, a chain or a rule.
+type row struct {
+	kind   rowKind
+	table  string
+	chain  string
+	policy string
+	rule   rulesfile.Rule
+}
+
+type RulesFilesModule struct {
+	paths        *focusablelist.SelectableList[string]
+	file         *rulesfile.File
+	rows         *focusablelist.SelectableList[row]
+	loadErr      error
+	deleteDialog *confirmation.ConfirmDialog
+	height       int
+
+	adding bool // typing a new rule for the focused chain
+	input  string
+}
+
+func Init(height int) RulesFilesModule {
+	m := RulesFilesModule{
+		paths:  focusablelist.FromList(rulesfile.Paths),
+		height: height,
+	}
+	return m.load()
+}
+
+func (m RulesFilesModule) load() RulesFilesModule {
+	m.file, m.loadErr = rulesfile.Load(m.paths.Focused())
+
+	var rows []row
+	if m.loadErr == nil {
+		for _, table := range m.file.Tables() {
+			rows = append(rows, row{kind: rowTable, table: table.Name})
+			for _, chain := range table.Chains {
+				rows = append(rows, row{kind: rowChain, table: table.Name, chain: chain.Name, policy: chain.Policy})
+				for _, rule := range chain.Rules {
+					rows = append(rows, row{kind: rowRule, table: table.Name, chain: chain.Name, rule: rule})
+				}
+			}
+		}
+	}
+
+	focused := -1
+	if m.rows != nil && len(m.rows.GetItems()) > 0 {
+		focused = lo.IndexOf(m.rows.GetItems(), m.rows.Focused())
+	}
+	m.rows = focusablelist.FromList(rows)
+	if m.height > 0 {
+		m.rows.SetHeight(m.height - listOverhead)
+	}
+	// keep the cursor near where it was after an edit
+	for i := 0; i < focused && i < len(rows)-1; i++ {
+		m.rows.Next()
+	}
+	return m
+}
+
+// UPDATE
+
+type RulesFilesEscMsg struct{}
+
+type fileChangedMsg struct{ Output string }
+
+func (mod RulesFilesModule) UpdateRulesFilesModule(msg tea.Msg) (RulesFilesModule, tea.Cmd) {
+	m := mod
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.rows.SetHeight(m.height - listOverhead)
+		return m, nil
+	case fileChangedMsg:
+		m = m.load()
+		return m, teacmd.OsCmdExecutionFinishedCmd(msg.Output)
+	}
+
+	if m.deleteDialog != nil {
+		newDeleteDialog, _, outMsg := m.deleteDialog.UpdateDialog(msg)
+		m.deleteDialog = newDeleteDialog
+		switch outMsg {
+		case confirmation.ConfirmationDialogYes:
+			m.deleteDialog = nil
+			rule := m.rows.Focused().rule
+			return m, m.saveCmd(func(f *rulesfile.File) error {
+				return f.RemoveRule(rule)
+			})
+		case confirmation.ConfirmationDialogNo, confirmation.ConfirmationDialogEsc:
+			m.deleteDialog = nil
+		}
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	key := keyMsg.String()
+
+	if m.adding {
+		switch key {
+		case "enter":
+			m.adding = false
+			focused := m.rows.Focused()
+			args := m.input
+			return m, m.saveCmd(func(f *rulesfile.File) error {
+				return f.AddRule(focused.table, focused.chain, args)
+			})
+		case "esc":
+			m.adding = false
+		case "backspace":
+			m.input = stringsext.TrimLastChar(m.input)
+		default:
+			m.input += teacmd.TypedText(keyMsg)
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "up", "k":
+		m.rows.Prev()
+	case "down", "j":
+		m.rows.Next()
+	case "pgup":
+		m.rows.PageUp()
+	case "pgdown":
+		m.rows.PageDown()
+	case "home":
+		m.rows.FocusFirst()
+	case "end":
+		m.rows.FocusLast()
+	case "tab":
+		m.paths.Next()
+		m.rows = nil
+		m = m.load()
+	case "shift+tab":
+		m.paths.Prev()
+		m.rows = nil
+		m = m.load()
+	case "r":
+		m = m.load()
+	case "esc":
+		return m, func() tea.Msg {
+			return RulesFilesEscMsg{}
+		}
+	}
+
+	if len(m.rows.GetItems()) == 0 {
+		return m, nil
+	}
+	focused := m.rows.Focused()
+	switch key {
+	case "a":
+		if focused.kind == rowTable {
+			return m, nil
+		}
+		m.adding = true
+		m.input = ""
+	case "c":
+		if focused.kind != rowRule {
+			return m, nil
+		}
+		return m, m.saveCmd(func(f *rulesfile.File) error {
+			return f.ToggleComment(focused.rule)
+		})
+	case "d", "delete":
+		if focused.kind != rowRule {
+			return m, nil
+		}
+		m.deleteDialog = confirmation.NewConfirmDialog(fmt.Sprintf("Remove this rule from %s?\n\n  %s", m.file.Path, focused.rule.Text))
+	}
+	return m, nil
+}
+
+// saveCmd applies change to a fresh copy of the file, then validates, backs up
+// and writes it and reloads ufw. Nothing is written when validation fails.
+func (m RulesFilesModule) saveCmd(change func(*rulesfile.File) error) tea.Cmd {
+	path := m.file.Path
+	return teacmd.RunOsCmdAndAfter(func() string {
+		file, err := rulesfile.Load(path)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		if err := change(file); err != nil {
+			return "Error: " + err.Error()
+		}
+		if err := file.Save(); err != nil {
+			return "Error: " + err.Error()
+		}
+		return fmt.Sprintf("Saved %s (backup in %s)\n", path, rulesfile.BackupDir) + ufw.Reload()
+	}, func(s string) tea.Msg {
+		return fileChangedMsg{Output: s}
+	})
+}
+
+// VIEW
+
+func (m RulesFilesModule) ViewRulesFiles() string {
+	if m.deleteDialog != nil {
+		return m.deleteDialog.ViewDialog()
+	}
+
+	tabs := lo.Map(m.paths.GetItems(), func(path string, _ int) string {
+		name := path[strings.LastIndex(path, "/")+1:]
+		return lo.Ternary(path == m.paths.Focused(), "["+name+"]", " "+name+" ")
+	})
+	lines := []string{strings.Join(tabs, " ") + "  " + m.rows.PositionIndicator()}
+
+	if m.loadErr != nil {
+		lines = append(lines, "  Error: "+m.loadErr.Error())
+	}
+	m.rows.ForEachVisible(func(r row, _ int, isSelected bool) {
+		prefix := lo.Ternary(isSelected, ">", " ")
+		switch r.kind {
+		case rowTable:
+			lines = append(lines, fmt.Sprintf("%s *%s", prefix, r.table))
+		case rowChain:
+			lines = append(lines, fmt.Sprintf("%s   :%s %s", prefix, r.chain, lo.CoalesceOrEmpty(r.policy, "(not declared)")))
+		case rowRule:
+			text := stringsext.Truncate(r.rule.Text, 200)
+			if r.rule.Commented {
+				text = stringsext.Faint("# " + text)
+			}
+			lines = append(lines, fmt.Sprintf("%s       %s", prefix, text))
+		}
+	})
+
+	if m.adding {
+		focused := m.rows.Focused()
+		lines = append(lines, "", fmt.Sprintf("New rule in %s/%s: -A %s %s_", focused.table, focused.chain, focused.chain, m.input))
+		return strings.Join(lines, "\n") + "\n\nType the rule options, Enter to validate and save, Esc to cancel"
+	}
+
+	output := strings.Join(lines, "\n")
+	output += "\n\n↑↓ PgUp/PgDn to navigate, Tab to switch file, a add rule to chain, c comment/uncomment, d remove, r reload, Esc to go back"
+	return output
+}
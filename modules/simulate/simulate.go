@@ -0,0 +1,203 @@
+package simulate
+
+import (
+	"fmt"
+	"fwtui/domain/entity"
+	"fwtui/domain/simulate"
+	"fwtui/domain/ufw"
+	"fwtui/modules/createrule"
+	"fwtui/modules/defaultpolicies"
+	"fwtui/utils/focusablelist"
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+// MODEL
+
+type Field string
+
+const (
+	FieldDirection   Field = "Direction"
+	FieldInterface   Field = "Interface"
+	FieldSource      Field = "Source IP"
+	FieldDestination Field = "Destination IP"
+	FieldPort        Field = "Port"
+	FieldProtocol    Field = "Protocol"
+)
+
+var fields = []Field{FieldDirection, FieldInterface, FieldSource, FieldDestination, FieldPort, FieldProtocol}
+
+type SimulateModule struct {
+	direction     *focusablelist.SelectableList[string]
+	interface_    *focusablelist.SelectableList[string]
+	source        string
+	destination   string
+	port          string
+	protocol      *focusablelist.SelectableList[string]
+	selectedField *focusablelist.SelectableList[Field]
+
+	verdict *simulate.Verdict
+	err     error
+}
+
+func Init() SimulateModule {
+	interfaces, _ := createrule.GetActiveInterfaces()
+
+	return SimulateModule{
+		direction:     focusablelist.FromList([]string{"in", "out"}),
+		interface_:    focusablelist.FromList(append(interfaces, "lo")),
+		protocol:      focusablelist.FromList([]string{"tcp", "udp"}),
+		selectedField: focusablelist.FromList(fields),
+	}
+}
+
+// UPDATE
+
+type SimulateEscMsg struct{}
+
+func (mod SimulateModule) UpdateSimulateModule(msg tea.Msg) (SimulateModule, tea.Cmd) {
+	m := mod
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		switch key {
+		case "up":
+			m.selectedField.Prev()
+		case "down":
+			m.selectedField.Next()
+		case "left", "right":
+			var list *focusablelist.SelectableList[string]
+			switch m.selectedField.Focused() {
+			case FieldDirection:
+				list = m.direction
+			case FieldInterface:
+				list = m.interface_
+			case FieldProtocol:
+				list = m.protocol
+			default:
+				return m, nil
+			}
+			if key == "left" {
+				list.Prev()
+			} else {
+				list.Next()
+			}
+		case "backspace":
+			if field := m.textField(); field != nil {
+				*field = stringsext.TrimLastChar(*field)
+			}
+		case "enter":
+			m = m.run()
+		case "esc":
+			return m, func() tea.Msg {
+				return SimulateEscMsg{}
+			}
+		default:
+			if field := m.textField(); field != nil {
+				*field += teacmd.TypedText(msg)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *SimulateModule) textField() *string {
+	switch m.selectedField.Focused() {
+	case FieldSource:
+		return &m.source
+	case FieldDestination:
+		return &m.destination
+	case FieldPort:
+		return &m.port
+	}
+	return nil
+}
+
+func (m SimulateModule) run() SimulateModule {
+	m.verdict, m.err = nil, nil
+
+	port, err := strconv.Atoi(m.port)
+	if err != nil {
+		m.err = fmt.Errorf("invalid port: %s", m.port)
+		return m
+	}
+	packet := simulate.Packet{
+		Direction:   m.direction.Focused(),
+		Interface:   m.interface_.Focused(),
+		Source:      strings.TrimSpace(m.source),
+		Destination: strings.TrimSpace(m.destination),
+		Port:        port,
+		Protocol:    m.protocol.Focused(),
+	}
+	if err := packet.Validate(); err != nil {
+		m.err = err
+		return m
+	}
+
+	policies := defaultpolicies.ParseUfwDefaults(ufw.StatusVerbose())
+	if policies.IsErr() {
+		m.err = fmt.Errorf("could not read default policies, is ufw enabled?")
+		return m
+	}
+	policy := lo.Ternary(packet.Direction == "in", policies.Value().Incoming, policies.Value().Outgoing)
+
+	rules := entity.ParseRules(ufw.StatusNumbered())
+	var apps map[string][]string
+	if lo.SomeBy(rules, func(r entity.Rule) bool { return r.To.App != "" }) {
+		installed, _ := entity.LoadInstalledProfiles()
+		apps = entity.ProfilePorts(installed)
+	}
+
+	verdict := simulate.Simulate(packet, rules, policy, apps)
+	m.verdict = &verdict
+	return m
+}
+
+// VIEW
+
+func (m SimulateModule) ViewSimulate() string {
+	lines := []string{"Simulate a connection against the current rules:", ""}
+
+	for _, field := range fields {
+		var value string
+		switch field {
+		case FieldDirection:
+			value = m.direction.Focused()
+		case FieldInterface:
+			value = lo.CoalesceOrEmpty(m.interface_.Focused(), "any")
+		case FieldSource:
+			value = m.source
+		case FieldDestination:
+			value = m.destination
+		case FieldPort:
+			value = m.port
+		case FieldProtocol:
+			value = m.protocol.Focused()
+		}
+		prefix := lo.Ternary(m.selectedField.Focused() == field, "> ", "  ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, field, value))
+	}
+
+	lines = append(lines, "")
+	switch {
+	case m.err != nil:
+		lines = append(lines, "Error: "+m.err.Error())
+	case m.verdict != nil:
+		lines = append(lines, "Verdict: "+strings.ToUpper(m.verdict.Action), m.verdict.Reason)
+		if m.verdict.Rule != nil {
+			lines = append(lines, "  "+m.verdict.Rule.Line)
+		}
+		if len(m.verdict.Skipped) > 0 {
+			numbers := lo.Map(m.verdict.Skipped, func(r entity.Rule, _ int) string { return strconv.Itoa(r.Number) })
+			lines = append(lines, fmt.Sprintf("Not evaluated (source port rules): %s", strings.Join(numbers, ", ")))
+		}
+	}
+
+	lines = append(lines, "", "↑↓ to navigate, ←→ to change selection, type to edit, Enter to simulate, Esc to go back")
+	return strings.Join(lines, "\n")
+}
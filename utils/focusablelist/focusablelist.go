@@ -1,8 +1,12 @@
 package focusablelist
 
+import "fmt"
+
 type SelectableList[T comparable] struct {
 	Items   []T
 	Current int // index of selected item
+	height  int // number of visible items, 0 until SetHeight is called shows all
+	offset  int // index of the first visible item
 }
 
 // FromList creates a new selectable list, defaulting to the first item.
@@ -17,6 +21,7 @@ func (s *SelectableList[T]) Focus(item T) *SelectableList[T] {
 	for i, v := range s.Items {
 		if v == item {
 			s.Current = i
+			s.scrollToCurrent()
 			return s
 		}
 	}
@@ -29,6 +34,7 @@ func (s *SelectableList[T]) Next() {
 		return
 	}
 	s.Current = (s.Current + 1) % len(s.Items)
+	s.scrollToCurrent()
 }
 
 // Prev moves selection backward (wraps around).
@@ -37,6 +43,22 @@ func (s *SelectableList[T]) Prev() {
 		return
 	}
 	s.Current = (s.Current - 1 + len(s.Items)) % len(s.Items)
+	s.scrollToCurrent()
+}
+
+// PageDown moves selection one page forward (stops at the last item).
+func (s *SelectableList[T]) PageDown() {
+	if len(s.Items) == 0 {
+		return
+	}
+	s.Current = min(s.Current+s.pageSize(), len(s.Items)-1)
+	s.scrollToCurrent()
+}
+
+// PageUp moves selection one page backward (stops at the first item).
+func (s *SelectableList[T]) PageUp() {
+	s.Current = max(s.Current-s.pageSize(), 0)
+	s.scrollToCurrent()
 }
 
 // Focused returns the currently selected item.
@@ -50,9 +72,33 @@ func (s *SelectableList[T]) ForEach(f func(item T, index int, isSelected bool))
 	}
 }
 
+// ForEachVisible is like ForEach but only visits the items inside the scroll window.
+func (s *SelectableList[T]) ForEachVisible(f func(item T, index int, isSelected bool)) {
+	start, end := s.window()
+	for i := start; i < end; i++ {
+		f(s.Items[i], i, i == s.Current)
+	}
+}
+
+// PositionIndicator describes the scroll position, e.g. "(12/340)".
+// It is empty when every item fits on screen.
+func (s *SelectableList[T]) PositionIndicator() string {
+	if s.height <= 0 || len(s.Items) <= s.height {
+		return ""
+	}
+	return fmt.Sprintf("(%d/%d)", s.Current+1, len(s.Items))
+}
+
 func (s *SelectableList[T]) FocusFirst() {
 	s.Current = 0
+	s.scrollToCurrent()
+}
+
+func (s *SelectableList[T]) FocusLast() {
+	s.Current = max(len(s.Items)-1, 0)
+	s.scrollToCurrent()
 }
+
 func (s *SelectableList[T]) GetItems() []T {
 	return s.Items
 }
@@ -61,4 +107,40 @@ func (s *SelectableList[T]) SetItems(items []T) {
 		s.Current = len(items) - 1
 	}
 	s.Items = items
+	s.scrollToCurrent()
+}
+
+// SetHeight sets how many items are visible at once (at least one).
+func (s *SelectableList[T]) SetHeight(height int) {
+	s.height = max(height, 1)
+	s.scrollToCurrent()
+}
+
+func (s *SelectableList[T]) pageSize() int {
+	if s.height <= 0 {
+		return len(s.Items)
+	}
+	return s.height
+}
+
+func (s *SelectableList[T]) window() (int, int) {
+	if s.height <= 0 {
+		return 0, len(s.Items)
+	}
+	return s.offset, min(s.offset+s.height, len(s.Items))
+}
+
+// scrollToCurrent moves the window so the current item stays visible.
+func (s *SelectableList[T]) scrollToCurrent() {
+	if s.height <= 0 {
+		s.offset = 0
+		return
+	}
+	if s.Current < s.offset {
+		s.offset = s.Current
+	}
+	if s.Current >= s.offset+s.height {
+		s.offset = s.Current - s.height + 1
+	}
+	s.offset = max(0, min(s.offset, len(s.Items)-s.height))
 }
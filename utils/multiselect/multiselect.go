@@ -1,13 +1,19 @@
 package multiselect
 
 import (
+	"fmt"
 	"fwtui/utils/set"
+	"slices"
 )
 
 type MultiSelectableList[T any] struct {
 	Items    []T
 	Focused  int          // Index of focused item
 	Selected set.Set[int] // Set of selected item indices
+	height   int          // Number of visible items, 0 until SetHeight is called shows all
+	offset   int          // Position of the first visible item in visible()
+	filter   func(T) bool // Items not matching are hidden, nil shows all
+	matching []int        // Indices of items matching filter
 }
 
 func FromList[T any](items []T) MultiSelectableList[T] {
@@ -19,20 +25,37 @@ func FromList[T any](items []T) MultiSelectableList[T] {
 }
 
 func (s *MultiSelectableList[T]) Next() {
-	if len(s.Items) == 0 {
+	visible := s.visible()
+	if len(visible) == 0 {
 		return
 	}
-	s.Focused = (s.Focused + 1) % len(s.Items)
+	s.Focused = visible[(s.focusedPosition()+1)%len(visible)]
+	s.scrollToFocused()
 }
 
 func (s *MultiSelectableList[T]) Prev() {
-	if len(s.Items) == 0 {
+	visible := s.visible()
+	if len(visible) == 0 {
 		return
 	}
-	s.Focused = (s.Focused - 1 + len(s.Items)) % len(s.Items)
+	s.Focused = visible[(s.focusedPosition()-1+len(visible))%len(visible)]
+	s.scrollToFocused()
+}
+
+// PageDown moves focus one page forward (stops at the last item).
+func (s *MultiSelectableList[T]) PageDown() {
+	s.focusPosition(s.focusedPosition() + s.pageSize())
+}
+
+// PageUp moves focus one page backward (stops at the first item).
+func (s *MultiSelectableList[T]) PageUp() {
+	s.focusPosition(s.focusedPosition() - s.pageSize())
 }
 
 func (s *MultiSelectableList[T]) Toggle() {
+	if s.IsEmpty() {
+		return
+	}
 	s.Selected.Toggle(s.Focused)
 }
 
@@ -48,6 +71,11 @@ func (s *MultiSelectableList[T]) NoneSelected() bool {
 	return s.Selected.IsEmpty()
 }
 
+// IsEmpty reports whether there is no visible item to focus.
+func (s *MultiSelectableList[T]) IsEmpty() bool {
+	return len(s.visible()) == 0
+}
+
 func (s *MultiSelectableList[T]) FocusedItem() T {
 	return s.Items[s.Focused]
 }
@@ -61,6 +89,24 @@ func (s *MultiSelectableList[T]) SetItems(items []T) {
 	}
 	s.Items = items
 	s.Selected = set.NewSet[int]()
+	s.applyFilter()
+}
+
+// SetHeight sets how many items are visible at once (at least one).
+func (s *MultiSelectableList[T]) SetHeight(height int) {
+	s.height = max(height, 1)
+	s.scrollToFocused()
+}
+
+// SetFilter hides items not matching filter. Indices (Focused, Selected) keep
+// referring to Items, so selection survives filtering.
+func (s *MultiSelectableList[T]) SetFilter(filter func(T) bool) {
+	s.filter = filter
+	s.applyFilter()
+}
+
+func (s *MultiSelectableList[T]) ClearFilter() {
+	s.SetFilter(nil)
 }
 
 func (s *MultiSelectableList[T]) GetSelectedItems() []T {
@@ -78,11 +124,123 @@ func (s *MultiSelectableList[T]) GetSelectedIndexes() []int {
 }
 
 func (s *MultiSelectableList[T]) FocusFirst() {
-	s.Focused = 0
+	s.focusPosition(0)
 }
 
+func (s *MultiSelectableList[T]) FocusLast() {
+	s.focusPosition(len(s.visible()) - 1)
+}
+
+// FocusIndex focuses the item at index i of Items, clearing a filter hiding it.
+func (s *MultiSelectableList[T]) FocusIndex(i int) {
+	if i < 0 || i >= len(s.Items) {
+		return
+	}
+	if !slices.Contains(s.visible(), i) {
+		s.ClearFilter()
+	}
+	s.Focused = i
+	s.scrollToFocused()
+}
+
+// ForEach visits every item, including the ones hidden by the filter.
 func (s *MultiSelectableList[T]) ForEach(f func(item T, index int, isFocused, isSelected bool)) {
 	for i, item := range s.Items {
 		f(item, i, i == s.Focused, s.Selected.Has(i))
 	}
 }
+
+// ForEachVisible is like ForEach but only visits the filtered items inside the scroll window.
+func (s *MultiSelectableList[T]) ForEachVisible(f func(item T, index int, isFocused, isSelected bool)) {
+	visible := s.visible()
+	start, end := s.window()
+	for _, i := range visible[start:end] {
+		f(s.Items[i], i, i == s.Focused, s.Selected.Has(i))
+	}
+}
+
+// PositionIndicator describes the scroll position, e.g. "(12/340)", or
+// "(3/12 of 340)" when filtered. It is empty when every item fits on screen.
+func (s *MultiSelectableList[T]) PositionIndicator() string {
+	visible := s.visible()
+	if s.filter != nil {
+		return fmt.Sprintf("(%d/%d of %d)", min(s.focusedPosition()+1, len(visible)), len(visible), len(s.Items))
+	}
+	if s.height <= 0 || len(visible) <= s.height {
+		return ""
+	}
+	return fmt.Sprintf("(%d/%d)", s.focusedPosition()+1, len(visible))
+}
+
+// visible returns the indices of items passing the filter.
+func (s *MultiSelectableList[T]) visible() []int {
+	if s.filter != nil {
+		return s.matching
+	}
+	all := make([]int, len(s.Items))
+	for i := range s.Items {
+		all[i] = i
+	}
+	return all
+}
+
+func (s *MultiSelectableList[T]) applyFilter() {
+	s.matching = nil
+	if s.filter != nil {
+		for i, item := range s.Items {
+			if s.filter(item) {
+				s.matching = append(s.matching, i)
+			}
+		}
+	}
+
+	visible := s.visible()
+	if len(visible) > 0 && !slices.Contains(visible, s.Focused) {
+		s.Focused = visible[0]
+	}
+	s.scrollToFocused()
+}
+
+func (s *MultiSelectableList[T]) focusedPosition() int {
+	return max(slices.Index(s.visible(), s.Focused), 0)
+}
+
+func (s *MultiSelectableList[T]) focusPosition(position int) {
+	visible := s.visible()
+	if len(visible) == 0 {
+		return
+	}
+	s.Focused = visible[max(0, min(position, len(visible)-1))]
+	s.scrollToFocused()
+}
+
+func (s *MultiSelectableList[T]) pageSize() int {
+	if s.height <= 0 {
+		return len(s.Items)
+	}
+	return s.height
+}
+
+func (s *MultiSelectableList[T]) window() (int, int) {
+	visible := len(s.visible())
+	if s.height <= 0 {
+		return 0, visible
+	}
+	return min(s.offset, visible), min(s.offset+s.height, visible)
+}
+
+// scrollToFocused moves the window so the focused item stays visible.
+func (s *MultiSelectableList[T]) scrollToFocused() {
+	if s.height <= 0 {
+		s.offset = 0
+		return
+	}
+	position := s.focusedPosition()
+	if position < s.offset {
+		s.offset = position
+	}
+	if position >= s.offset+s.height {
+		s.offset = position - s.height + 1
+	}
+	s.offset = max(0, min(s.offset, len(s.visible())-s.height))
+}
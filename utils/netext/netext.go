@@ -0,0 +1,121 @@
+package netext
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// ParseIPOrCIDR parses "10.0.0.0/8" or a single address, which is returned as a /32 (or /128) network.
+func ParseIPOrCIDR(s string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP or CIDR: %s", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// Contains reports whether every address of inner is inside outer.
+func Contains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// Overlaps reports whether the two networks share at least one address.
+func Overlaps(a, b *net.IPNet) bool {
+	return Contains(a, b) || Contains(b, a)
+}
+
+// AggregatePrefixes removes duplicates and networks covered by others and merges
+// adjacent networks into their parent, e.g. 10.0.0.0/25 and 10.0.0.128/25
+// into 10.0.0.0/24.
+func AggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		sorted = append(sorted, unmapPrefix(prefix).Masked())
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Addr().Compare(sorted[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Bits() < sorted[j].Bits()
+	})
+
+	var result []netip.Prefix
+	for _, prefix := range sorted {
+		if n := len(result); n > 0 && result[n-1].Bits() <= prefix.Bits() && result[n-1].Contains(prefix.Addr()) {
+			continue
+		}
+		result = append(result, prefix)
+
+		for len(result) >= 2 {
+			a, b := result[len(result)-2], result[len(result)-1]
+			if a.Bits() != b.Bits() || a.Bits() == 0 {
+				break
+			}
+			parentA, _ := a.Addr().Prefix(a.Bits() - 1)
+			parentB, _ := b.Addr().Prefix(b.Bits() - 1)
+			if parentA != parentB {
+				break
+			}
+			result = append(result[:len(result)-2], parentA)
+		}
+	}
+	return result
+}
+
+// RangeToPrefixes covers the inclusive address range with the fewest networks.
+func RangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for start.IsValid() && start.Compare(end) <= 0 {
+		bits := start.BitLen()
+		// widen the network while it stays aligned to start and ends before end
+		for bits > 0 {
+			wider := netip.PrefixFrom(start, bits-1).Masked()
+			if wider.Addr() != start || lastAddr(wider).Compare(end) > 0 {
+				break
+			}
+			bits--
+		}
+		prefix := netip.PrefixFrom(start, bits)
+		prefixes = append(prefixes, prefix)
+
+		last := lastAddr(prefix)
+		if last == end {
+			break
+		}
+		start = last.Next()
+	}
+	return prefixes
+}
+
+// unmapPrefix turns an IPv4-mapped network such as ::ffff:1.2.3.0/120 into
+// 1.2.3.0/24. Networks wider than the mapped range stay IPv6.
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	if !prefix.Addr().Is4In6() || prefix.Bits() < 96 {
+		return prefix
+	}
+	return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Masked().Addr().As16()
+	offset := 128 - prefix.Addr().BitLen() // IPv4 lives in the last 4 bytes
+	for bit := offset + prefix.Bits(); bit < 128; bit++ {
+		bytes[bit/8] |= 1 << (7 - bit%8)
+	}
+	addr := netip.AddrFrom16(bytes)
+	if prefix.Addr().Is4() {
+		return addr.Unmap()
+	}
+	return addr
+}
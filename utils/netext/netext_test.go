@@ -0,0 +1,76 @@
+package netext
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestAggregatePrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"empty", nil, nil},
+		{"duplicates", []string{"10.0.0.0/8", "10.0.0.0/8"}, []string{"10.0.0.0/8"}},
+		{"covered", []string{"10.1.0.0/16", "10.0.0.0/8", "10.2.3.4/32"}, []string{"10.0.0.0/8"}},
+		{"adjacent halves", []string{"10.0.0.128/25", "10.0.0.0/25"}, []string{"10.0.0.0/24"}},
+		{"merges upwards", []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/25"}, []string{"10.0.0.0/24"}},
+		{"not siblings", []string{"10.0.0.128/25", "10.0.1.0/25"}, []string{"10.0.0.128/25", "10.0.1.0/25"}},
+		{"host bits masked", []string{"192.168.1.77/24"}, []string{"192.168.1.0/24"}},
+		{"ipv4-mapped", []string{"::ffff:1.2.3.0/120", "1.2.3.0/24"}, []string{"1.2.3.0/24"}},
+		{"ipv4-mapped host", []string{"::ffff:1.2.3.4/128"}, []string{"1.2.3.4/32"}},
+		{"wider than the mapped range", []string{"::ffff:0:0/80"}, []string{"::/80"}},
+		{"ipv6", []string{"2001:db8::/33", "2001:db8:8000::/33", "2001:db9::/48"}, []string{"2001:db8::/32", "2001:db9::/48"}},
+		{"mixed families", []string{"2001:db8::/32", "10.0.0.0/8"}, []string{"10.0.0.0/8", "2001:db8::/32"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in []netip.Prefix
+			for _, s := range tt.in {
+				in = append(in, netip.MustParsePrefix(s))
+			}
+			got := prefixStrings(AggregatePrefixes(in))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, prefix := range AggregatePrefixes(in) {
+				if !prefix.IsValid() {
+					t.Errorf("invalid prefix %v", prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.5", "10.0.0.5", []string{"10.0.0.5/32"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"1.0.0.0", "1.0.3.255", []string{"1.0.0.0/22"}},
+		{"2001:db8::", "2001:db8::ffff", []string{"2001:db8::/112"}},
+		{"10.0.0.9", "10.0.0.1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end, func(t *testing.T) {
+			got := prefixStrings(RangeToPrefixes(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func prefixStrings(prefixes []netip.Prefix) []string {
+	var result []string
+	for _, prefix := range prefixes {
+		result = append(result, prefix.String())
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package query
+
+import (
+	stringsext "fwtui/utils/strings"
+	"fwtui/utils/teacmd"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Input is a "/" filter line edited in place above a list.
+type Input struct {
+	Editing bool
+	Text    string
+}
+
+// HandleKey processes a key press. handled reports whether the key was consumed
+// and changed whether the filter text was modified.
+func (i *Input) HandleKey(msg tea.KeyMsg) (handled bool, changed bool) {
+	key := msg.String()
+	if !i.Editing {
+		if key == "/" {
+			i.Editing = true
+			return true, false
+		}
+		return false, false
+	}
+
+	switch key {
+	case "enter":
+		i.Editing = false
+		return true, false
+	case "esc":
+		i.Editing = false
+		i.Text = ""
+		return true, true
+	case "backspace":
+		i.Text = stringsext.TrimLastChar(i.Text)
+	case "up", "down", "left", "right", "tab", "pgup", "pgdown", "home", "end":
+		return false, false
+	default:
+		typed := teacmd.TypedText(msg)
+		if typed == "" {
+			return true, false // other key names must not reach the list while typing
+		}
+		i.Text += typed
+	}
+	return true, true
+}
+
+func (i Input) Query() Query {
+	return Parse(i.Text)
+}
+
+func (i Input) IsActive() bool {
+	return i.Text != ""
+}
+
+func (i Input) View() string {
+	switch {
+	case i.Editing:
+		return "Filter: " + i.Text + "_"
+	case i.Text != "":
+		return "Filter: " + i.Text
+	}
+	return ""
+}
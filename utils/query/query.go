@@ -0,0 +1,57 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Term is one whitespace separated part of a query, either free text
+// ("5432") or a field query ("port:5432").
+type Term struct {
+	Field string
+	Value string
+}
+
+type Query []Term
+
+// Parse splits a query into terms. A term is a field query only when the part
+// before the first colon is a word, so IPv6 addresses stay free text.
+func Parse(s string) Query {
+	var q Query
+	for _, part := range strings.Fields(s) {
+		field, value, found := strings.Cut(part, ":")
+		if found && isWord(field) {
+			q = append(q, Term{Field: strings.ToLower(field), Value: value})
+		} else {
+			q = append(q, Term{Value: part})
+		}
+	}
+	return q
+}
+
+// Matches reports whether every term is matched by matchTerm.
+func (q Query) Matches(matchTerm func(Term) bool) bool {
+	for _, term := range q {
+		if !matchTerm(term) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsFold is a case-insensitive strings.Contains.
+func ContainsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,8 +1,29 @@
 package stringsext
 
+import "unicode/utf8"
+
+// TrimLastChar drops the last rune of s.
 func TrimLastChar(s string) string {
 	if len(s) > 0 {
-		return s[:len(s)-1]
+		_, size := utf8.DecodeLastRuneInString(s)
+		return s[:len(s)-size]
 	}
 	return s
 }
+
+// Truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// Faint renders s dimmed on terminals supporting ANSI styles.
+func Faint(s string) string {
+	return "\x1b[2m" + s + "\x1b[22m"
+}
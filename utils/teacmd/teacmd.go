@@ -27,3 +27,12 @@ func OsCmdExecutionFinishedCmd(output string) tea.Cmd {
 		}
 	}
 }
+
+// TypedText returns the text a key press types into an input: the runes of
+// printable keys and pasted text, nothing for named keys such as "tab".
+func TypedText(msg tea.KeyMsg) string {
+	if (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt {
+		return string(msg.Runes)
+	}
+	return ""
+}